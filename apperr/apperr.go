@@ -0,0 +1,127 @@
+// Package apperr holds the errors handlers return instead of writing error
+// responses themselves. Handler turns them into RFC 7807 problem responses.
+package apperr
+
+import (
+	"net/http"
+)
+
+// Stable error codes. Clients branch on these, so never change one.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnprocessable    = "unprocessable"
+	CodeStale            = "precondition_failed"
+	CodeIfMatchRequired  = "precondition_required"
+	CodeInternal         = "internal_error"
+	CodeRouteNotFound    = "route_not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+)
+
+// Error is a failure a handler reports to the client.
+type Error struct {
+	Status int    // HTTP status
+	Code   string // stable and machine-readable, e.g. not_found
+	Detail string // human-readable, safe to show the client
+	Fields []FieldError
+	Extra  map[string]interface{} // extension members of the problem, e.g. the blocking items
+	Err    error                  // the cause, logged but never sent
+}
+
+// FieldError is one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"` // as named in the JSON body or query string
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithCode replaces the generic code with a more specific one.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// With adds an extension member to the problem.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extra == nil {
+		e.Extra = map[string]interface{}{}
+	}
+	e.Extra[key] = value
+	return e
+}
+
+func newError(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// BadRequest is a request that can't be read at all, such as malformed JSON.
+func BadRequest(detail string) *Error {
+	return newError(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+// Invalid is a readable request with bad values. fields names the culprits
+// when they are known.
+func Invalid(detail string, fields ...FieldError) *Error {
+	e := newError(http.StatusBadRequest, CodeValidation, detail)
+	e.Fields = fields
+	return e
+}
+
+// Field is shorthand for a FieldError.
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+func Unauthorized(detail string) *Error {
+	return newError(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+func Forbidden(detail string) *Error {
+	return newError(http.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(detail string) *Error {
+	return newError(http.StatusNotFound, CodeNotFound, detail)
+}
+
+// Conflict is a request at odds with the current state, such as a WIP limit.
+func Conflict(detail string) *Error {
+	return newError(http.StatusConflict, CodeConflict, detail)
+}
+
+// Unprocessable is a well-formed request that can't be carried out as sent,
+// such as a reused Idempotency-Key with a different body.
+func Unprocessable(detail string) *Error {
+	return newError(http.StatusUnprocessableEntity, CodeUnprocessable, detail)
+}
+
+// PreconditionFailed is a write based on an out of date copy, such as a stale
+// If-Match.
+func PreconditionFailed(detail string) *Error {
+	return newError(http.StatusPreconditionFailed, CodeStale, detail)
+}
+
+// PreconditionRequired is a write that must say which version it changes.
+func PreconditionRequired(detail string) *Error {
+	return newError(http.StatusPreconditionRequired, CodeIfMatchRequired, detail)
+}
+
+// Internal is a failure on our side. The client only sees detail; err is
+// logged with the request ID.
+func Internal(detail string, err error) *Error {
+	e := newError(http.StatusInternalServerError, CodeInternal, detail)
+	e.Err = err
+	return e
+}
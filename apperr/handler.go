@@ -0,0 +1,99 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Problem is the body of an error response, per RFC 7807. An Error's Extra
+// members are added alongside these.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"` // per-field validation failures
+}
+
+// Handler is the app's fiber.Config.ErrorHandler. Errors from this package are
+// sent as they are, Fiber's own errors (unknown route, body too large) keep
+// their status, and anything else is logged and sent as a bare 500.
+func Handler(c *fiber.Ctx, err error) error {
+	var e *Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &e):
+	case errors.As(err, &fiberErr):
+		e = fromFiber(fiberErr)
+	default:
+		e = Internal("Something went wrong", err)
+	}
+
+	requestID, _ := c.Locals("requestid").(string)
+	if e.Status >= http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %v", requestID, c.Method(), c.Path(), err)
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Detail,
+		Instance:  c.OriginalURL(),
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+	if len(e.Extra) == 0 {
+		return c.Status(e.Status).JSON(problem, ContentType)
+	}
+
+	// Extension members sit next to the standard ones but never replace them
+	raw, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return err
+	}
+	for key, value := range e.Extra {
+		if _, taken := body[key]; !taken {
+			body[key] = value
+		}
+	}
+	return c.Status(e.Status).JSON(body, ContentType)
+}
+
+// fromFiber maps errors Fiber raises before a handler runs.
+func fromFiber(err *fiber.Error) *Error {
+	code := strings.ReplaceAll(strings.ToLower(http.StatusText(err.Code)), " ", "_")
+	switch err.Code {
+	case fiber.StatusNotFound:
+		code = CodeRouteNotFound
+	case fiber.StatusMethodNotAllowed:
+		code = CodeMethodNotAllowed
+	case fiber.StatusBadRequest:
+		code = CodeBadRequest
+	}
+	if code == "" {
+		code = CodeInternal
+	}
+	e := newError(err.Code, code, err.Message)
+	if err.Code >= http.StatusInternalServerError {
+		e.Detail = "Something went wrong"
+		e.Err = err
+	}
+	return e
+}
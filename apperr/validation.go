@@ -0,0 +1,55 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validation turns a validator error into an Invalid error with one entry per
+// failed field. Fields are named by whatever the validator's tag name func
+// returns, so register one that reads the json tag.
+func Validation(err error) *Error {
+	var failures validator.ValidationErrors
+	if !errors.As(err, &failures) {
+		return Invalid("Validation failed")
+	}
+	fields := make([]FieldError, len(failures))
+	for i, f := range failures {
+		fields[i] = Field(fieldPath(f), ruleMessage(f))
+	}
+	return Invalid("Validation failed", fields...)
+}
+
+// fieldPath drops the struct name validator puts first: BoardRequest.title
+// becomes title.
+func fieldPath(f validator.FieldError) string {
+	if _, path, ok := strings.Cut(f.Namespace(), "."); ok {
+		return path
+	}
+	return f.Field()
+}
+
+func ruleMessage(f validator.FieldError) string {
+	switch f.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "min", "max":
+		bound := "at least"
+		if f.Tag() == "max" {
+			bound = "at most"
+		}
+		if f.Kind() == reflect.String {
+			return fmt.Sprintf("must be %s %s characters long", bound, f.Param())
+		}
+		return fmt.Sprintf("must be %s %s", bound, f.Param())
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(f.Param(), " ", ", ")
+	}
+	return fmt.Sprintf("failed the %s rule", f.Tag())
+}
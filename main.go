@@ -7,10 +7,13 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/healthcheck"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/clem-kay/mini-trello/config"
 	"github.com/clem-kay/mini-trello/models"
 	"github.com/clem-kay/mini-trello/routes"
+	"github.com/clem-kay/mini-trello/services"
 	"github.com/clem-kay/mini-trello/utils"
 )
 
@@ -18,15 +21,21 @@ func main() {
 	setup()
 	log.Println("Mini Trello application started successfully!")
 
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: apperr.Handler, // every error goes out as application/problem+json
+	})
 
-	app.Use(logger.New())
+	app.Use(requestid.New())
+	app.Use(logger.New(logger.Config{
+		Format: "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${locals:requestid} | ${error}\n",
+	}))
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: utils.GetEnv("ALLOW_ORIGINS", "*"),
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  utils.GetEnv("ALLOW_ORIGINS", "*"),
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, If-Match, Idempotency-Key",
+		ExposeHeaders: fiber.HeaderXRequestID + ", " + fiber.HeaderETag + ", Idempotent-Replayed",
 	}))
 
-	routes.RegisterAuthorRoutes(app)
+	routes.RegisterRoutes(app)
 
 	app.Use(healthcheck.New(healthcheck.Config{
 		LivenessProbe: func(c *fiber.Ctx) bool {
@@ -55,6 +64,42 @@ func setup() {
 	}
 	log.Println("Database connection established successfully.")
 
-	config.DB.AutoMigrate(&models.User{}, &models.Board{})
+	config.DB.AutoMigrate(
+		&models.User{},
+		&models.Board{},
+		&models.ProjectItem{},
+		&models.ItemDependency{},
+		&models.ItemRecurrence{},
+		&models.Worklog{},
+		&models.CustomField{},
+		&models.CustomFieldValue{},
+		&models.BoardStatus{},
+		&models.StatusTransition{},
+		&models.WIPOverride{},
+		&models.Watch{},
+		&models.SubscriptionDefaults{},
+		&models.BoardTemplate{},
+		&models.ItemTemplate{},
+		&models.BoardStar{},
+		&models.RecentView{},
+		&models.SavedView{},
+		&models.IdempotencyKey{},
+		&models.BoardMember{},
+	)
+	if err := services.MigrateDefaultWorkflows(); err != nil {
+		log.Fatal("Failed to migrate board workflows:", err)
+	}
 	log.Println("Database migrated successfully.")
+
+	if err := services.InitVersioning(config.DB); err != nil {
+		log.Fatal("Failed to set up row versions:", err)
+	}
+
+	if err := services.InitSearchIndex(); err != nil {
+		log.Fatal("Failed to set up search:", err)
+	}
+
+	services.StartRecurrenceScheduler()
+	services.StartTrashPurger()
+	services.StartIdempotencyPurger()
 }
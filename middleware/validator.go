@@ -2,63 +2,88 @@
 package middleware
 
 import (
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/clem-kay/mini-trello/utils"
 )
 
-var validate = validator.New()
+var validate = newValidator()
 
-func ValidateBody() fiber.Handler {
+// newValidator names fields after their json tag, as clients know them.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// ValidateBody parses the JSON body into a T and checks its validate tags,
+// answering 400 with one entry per failed field. The handler gets the parsed
+// body as a *T in c.Locals("body").
+func ValidateBody[T any]() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		body := c.Locals("body") // or parse directly
+		body := new(T)
+		if err := c.BodyParser(body); err != nil {
+			return apperr.BadRequest("Cannot parse JSON")
+		}
 		if err := validate.Struct(body); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error":   "Validation failed",
-				"details": err.Error(),
-			})
+			return apperr.Validation(err)
 		}
+		c.Locals("body", body)
 		return c.Next()
 	}
 }
 
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		authHeader := c.Get("Authorization")
-
-		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Missing or invalid token",
-			})
+		if c.Get("Authorization") == "" {
+			return apperr.Unauthorized("Missing or invalid token")
 		}
 
-		//Token validation logic here. take the logicfrom the auth header
-		bearerToken:= strings.Split(authHeader, "")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			return c. Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid authorization header format. Expected 'bearer <token>'",
-			})
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			return apperr.Unauthorized("Invalid authorization header format. Expected 'Bearer <token>'")
 		}
 
-		tokenString := bearerToken[1]
-
 		userID, err := utils.GetUserIDFromToken(tokenString)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid or expired token" + err.Error(),
-				// Optional: for debugging — remove in prod
-				// "details": err.Error(),
-			})
+			return apperr.Unauthorized("Invalid or expired token")
 		}
 
 		// ✅ Attach to context
 		c.Locals("user_id", userID)
 
-		// Optional: Also extract and attach email if needed
-		// (You can create a similar helper: GetEmailFromToken)
+		return c.Next()
+	}
+}
 
+// OptionalAuthMiddleware attaches the user when a valid bearer token is sent,
+// and lets anonymous requests through on public routes.
+func OptionalAuthMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if tokenString, ok := bearerToken(c); ok {
+			if userID, err := utils.GetUserIDFromToken(tokenString); err == nil {
+				c.Locals("user_id", userID)
+			}
+		}
 		return c.Next()
 	}
 }
+
+// bearerToken reads the token from an "Authorization: Bearer <token>" header.
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	parts := strings.SplitN(c.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// whoAmI answers with the user the auth middleware attached, 0 for none.
+func whoAmI(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"user_id": utils.GetIDFromContext(c)})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	token, err := utils.GenerateJWT(42, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Get("/", AuthMiddleware(), whoAmI)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid token", "Bearer " + token, fiber.StatusOK},
+		{"no header", "", fiber.StatusUnauthorized},
+		{"no scheme", token, fiber.StatusUnauthorized},
+		{"other scheme", "Basic " + token, fiber.StatusUnauthorized},
+		{"empty token", "Bearer ", fiber.StatusUnauthorized},
+		{"bad token", "Bearer not-a-jwt", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	token, err := utils.GenerateJWT(42, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/", OptionalAuthMiddleware(), whoAmI)
+
+	for header, want := range map[string]string{
+		"Bearer " + token:  `{"user_id":42}`,
+		"":                 `{"user_id":0}`,
+		"Bearer not-a-jwt": `{"user_id":0}`,
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if got := string(body); resp.StatusCode != fiber.StatusOK || got != want {
+			t.Errorf("Authorization %q: got %d %s, want 200 %s", header, resp.StatusCode, got, want)
+		}
+	}
+}
+
+type widgetRequest struct {
+	Name string `json:"name" validate:"required,max=5"`
+	Size int    `json:"size" validate:"omitempty,min=1"`
+}
+
+func TestValidateBody(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Post("/", ValidateBody[widgetRequest](), func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("body").(*widgetRequest).Name)
+	})
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"name":"bolt","size":2}`, fiber.StatusOK, "bolt"},
+		{`{"size":2}`, fiber.StatusBadRequest, `"errors":[{"field":"name","message":"is required"}]`},
+		{`{"name":"sprocket","size":0}`, fiber.StatusBadRequest, `"errors":[{"field":"name","message":"must be at most 5 characters long"}]`},
+		{`{"name":"bolt","size":-1}`, fiber.StatusBadRequest, `"errors":[{"field":"size","message":"must be at least 1"}]`},
+		{`{"name":`, fiber.StatusBadRequest, `"code":"bad_request"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.want) {
+			t.Errorf("%s: got %d %s, want %d with %s", tt.body, resp.StatusCode, body, tt.status, tt.want)
+		}
+	}
+}
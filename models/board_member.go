@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// BoardMember gives a user access to a board they don't own. Admins may also
+// change the board's configuration: custom fields, workflow, WIP limits,
+// default view and members.
+type BoardMember struct {
+	ID        uint       `gorm:"primarykey" json:"-"`
+	BoardID   uint       `gorm:"not null;uniqueIndex:idx_board_member" json:"board_id"`
+	UserID    uint       `gorm:"not null;uniqueIndex:idx_board_member;index" json:"user_id"`
+	Role      MemberRole `gorm:"type:varchar(10);not null;default:'member'" json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User  *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MemberRole is what a member may do on a board.
+type MemberRole string
+
+const (
+	MemberRoleMember MemberRole = "member" // reads and edits items
+	MemberRoleAdmin  MemberRole = "admin"  // also changes the board's configuration
+)
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomField is a typed, board-specific attribute that items on the board can carry.
+type CustomField struct {
+	gorm.Model
+	BoardID   uint            `gorm:"not null;uniqueIndex:idx_board_field_key" json:"board_id"`
+	Key       string          `gorm:"column:field_key;size:50;not null;uniqueIndex:idx_board_field_key" json:"key"` // used in payloads and filters
+	Name      string          `gorm:"size:100;not null" json:"name"`
+	Type      CustomFieldType `gorm:"type:varchar(20);not null" json:"type"`
+	Options   []string        `gorm:"serializer:json" json:"options,omitempty"` // choices for select fields
+	Required  bool            `gorm:"default:false" json:"required"`
+	Min       *float64        `json:"min,omitempty"`        // number fields
+	Max       *float64        `json:"max,omitempty"`        // number fields
+	MaxLength int             `json:"max_length,omitempty"` // text fields
+	Position  int             `gorm:"default:0" json:"position"`
+
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type CustomFieldType string
+
+const (
+	FieldText         CustomFieldType = "text"
+	FieldNumber       CustomFieldType = "number"
+	FieldDate         CustomFieldType = "date"
+	FieldSingleSelect CustomFieldType = "single_select"
+	FieldMultiSelect  CustomFieldType = "multi_select"
+)
+
+// CustomFieldValue holds one item's value for one field. Only the column
+// matching the field type is set, so values can be filtered and sorted in SQL.
+// Multi-select values are stored as a JSON array in TextValue.
+type CustomFieldValue struct {
+	ID          uint       `gorm:"primarykey" json:"-"`
+	ItemID      uint       `gorm:"not null;uniqueIndex:idx_item_field" json:"item_id"`
+	FieldID     uint       `gorm:"not null;uniqueIndex:idx_item_field;index" json:"field_id"`
+	TextValue   string     `gorm:"size:1024" json:"-"`
+	NumberValue *float64   `gorm:"index" json:"-"`
+	DateValue   *time.Time `gorm:"index" json:"-"`
+
+	Item  *ProjectItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+	Field *CustomField `gorm:"foreignKey:FieldID;constraint:OnDelete:CASCADE" json:"-"`
+}
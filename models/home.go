@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// BoardStar pins a board to a user's home page. Position orders the stars.
+type BoardStar struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_star_user_board" json:"user_id"`
+	BoardID   uint      `gorm:"not null;uniqueIndex:idx_star_user_board" json:"board_id"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User  *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// RecentView remembers when a user last opened a board or an item (exactly one is set).
+type RecentView struct {
+	ID       uint      `gorm:"primarykey" json:"-"`
+	UserID   uint      `gorm:"not null;uniqueIndex:idx_recent_board;uniqueIndex:idx_recent_item;index:idx_recent_user_viewed" json:"user_id"`
+	BoardID  *uint     `gorm:"uniqueIndex:idx_recent_board" json:"board_id,omitempty"`
+	ItemID   *uint     `gorm:"uniqueIndex:idx_recent_item" json:"item_id,omitempty"`
+	ViewedAt time.Time `gorm:"not null;index:idx_recent_user_viewed" json:"viewed_at"`
+
+	User  *User        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board       `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+	Item  *ProjectItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+}
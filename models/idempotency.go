@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers a create request sent with an Idempotency-Key
+// header and the response it got, so a retry replays the response instead of
+// creating the resource again. Status stays 0 while the first request runs.
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primarykey" json:"-"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_idempotency_key" json:"-"`
+	Key         string    `gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idx_idempotency_key" json:"-"`
+	Fingerprint string    `gorm:"size:64;not null" json:"-"` // SHA-256 of the method, path and body
+	Status      int       `gorm:"not null;default:0" json:"-"`
+	ContentType string    `gorm:"size:100" json:"-"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"-"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"-"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ItemDependency records that BlockerID must be finished before BlockedID can be.
+type ItemDependency struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	BlockerID uint      `gorm:"not null;uniqueIndex:idx_item_dependency" json:"blocker_id"`
+	BlockedID uint      `gorm:"not null;uniqueIndex:idx_item_dependency;index" json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Blocker *ProjectItem `gorm:"foreignKey:BlockerID;constraint:OnDelete:CASCADE" json:"-"`
+	Blocked *ProjectItem `gorm:"foreignKey:BlockedID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ItemRecurrence is a series of items generated from an iCalendar RRULE.
+// Each occurrence is a regular ProjectItem pointing back to the series.
+type ItemRecurrence struct {
+	gorm.Model
+	RRule         string         `gorm:"size:255;not null" json:"rrule"`
+	Timezone      string         `gorm:"size:64;not null;default:'UTC'" json:"timezone"`
+	Mode          RecurrenceMode `gorm:"type:varchar(20);default:'on_complete'" json:"mode"`
+	DTStart       time.Time      `gorm:"not null" json:"dtstart"`      // due date of the first occurrence
+	CurrentItemID uint           `gorm:"index" json:"current_item_id"` // the latest generated occurrence
+	Active        bool           `gorm:"default:true;index" json:"active"`
+}
+
+type RecurrenceMode string
+
+const (
+	RecurOnComplete RecurrenceMode = "on_complete" // next occurrence is created when the current one is done
+	RecurOnSchedule RecurrenceMode = "on_schedule" // next occurrence is created once the current one is due
+)
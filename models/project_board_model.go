@@ -1,13 +1,46 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Board struct {
 	gorm.Model
-	Name        string        `gorm:"size:100;not null" json:"name"`
-	Description string        `gorm:"size:255" json:"description"`
-	UserID      uint          `gorm:"not null;index" json:"user_id"`
+	Name         string       `gorm:"size:100;not null;index:ft_board_search,class:FULLTEXT" json:"name"`
+	Description  string       `gorm:"size:255;index:ft_board_search,class:FULLTEXT" json:"description"`
+	UserID       uint         `gorm:"not null;index" json:"user_id"`
+	EstimateUnit EstimateUnit `gorm:"type:varchar(10);default:'points'" json:"estimate_unit"`
+	// When set, items cannot be moved to a complete status while any of their blockers is still open
+	EnforceBlockers bool       `gorm:"not null;default:false" json:"enforce_blockers"`
+	ArchivedAt      *time.Time `gorm:"index" json:"archived_at,omitempty"` // archived boards are hidden from listings but not deleted
+	DefaultViewID   *uint      `json:"default_view_id,omitempty"`          // shared view the board opens with
+	Version         uint       `gorm:"not null;default:1" json:"version"`  // bumped on every write, sent as the ETag
+	TrashBatch      string     `gorm:"size:32" json:"-"`                   // the items deleted with the board share it
 
 	User  *User         `gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL" json:"-"`
 	Items []ProjectItem `gorm:"foreignKey:BoardID" json:"-"` // optional
-}
\ No newline at end of file
+
+	// Computed on read, never stored
+	Estimates *EstimateSummary `gorm:"-" json:"estimates,omitempty"`
+	Columns   []BoardStatus    `gorm:"-" json:"columns,omitempty"` // workflow with current load and WIP limits
+}
+
+// EstimateUnit is how a board sizes its items.
+type EstimateUnit string
+
+const (
+	EstimatePoints EstimateUnit = "points"
+	EstimateHours  EstimateUnit = "hours"
+)
+
+// EstimateSummary rolls up item estimates on a board.
+type EstimateSummary struct {
+	Unit      EstimateUnit `json:"unit"`
+	Total     float64      `json:"total"`
+	Done      float64      `json:"done"`
+	Remaining float64      `json:"remaining"`
+	Estimated int          `json:"estimated_items"`
+	Items     int          `json:"items"`
+}
@@ -8,18 +8,44 @@ import (
 
 type ProjectItem struct {
 	gorm.Model
-	Name        string       `gorm:"size:50;not null" json:"name"`
-	BoardID     uint         `gorm:"not null;index" json:"board_id"`
-	Description string       `gorm:"size:255" json:"description"`
-	DueDate     *time.Time   `json:"due_date,omitempty"` // optional
-	Status      ItemStatus   `gorm:"type:varchar(20);default:'todo';index" json:"status"` // safer for cross-db
-	Priority    ItemPriority `gorm:"type:varchar(10);default:'medium';index" json:"priority"`
-
-	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+	Name         string       `gorm:"size:50;not null;index:ft_item_search,class:FULLTEXT" json:"name"`
+	BoardID      uint         `gorm:"not null;index" json:"board_id"`
+	Description  string       `gorm:"size:255;index:ft_item_search,class:FULLTEXT" json:"description"`
+	DueDate      *time.Time   `json:"due_date,omitempty"`                                  // optional
+	Status       ItemStatus   `gorm:"type:varchar(20);default:'todo';index" json:"status"` // safer for cross-db
+	Priority     ItemPriority `gorm:"type:varchar(10);default:'medium';index" json:"priority"`
+	ParentID     *uint        `gorm:"index" json:"parent_id,omitempty"`     // optional, makes this item a subtask
+	RecurrenceID *uint        `gorm:"index" json:"recurrence_id,omitempty"` // set on every occurrence of a recurring item
+	Estimate     *float64     `gorm:"index" json:"estimate,omitempty"`      // in the board's estimate unit
+	ArchivedAt   *time.Time   `gorm:"index" json:"archived_at,omitempty"`   // archived items are hidden from listings but not deleted
+	Version      uint         `gorm:"not null;default:1" json:"version"`    // bumped on every write, sent as the ETag
+	TrashBatch   string       `gorm:"size:32;index" json:"-"`               // shared by everything deleted together, which is restored and purged together
+
+	Board    *Board        `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+	Parent   *ProjectItem  `gorm:"foreignKey:ParentID" json:"-"`
+	Children []ProjectItem `gorm:"foreignKey:ParentID" json:"-"`
+
+	// Computed on read, never stored
+	Subtasks     *SubtaskSummary        `gorm:"-" json:"subtasks,omitempty"`
+	BlockedBy    []uint                 `gorm:"-" json:"blocked_by"`
+	Blocks       []uint                 `gorm:"-" json:"blocks"`
+	TimeSpent    int64                  `gorm:"-" json:"time_spent_seconds"`      // finished worklogs only
+	CustomFields map[string]interface{} `gorm:"-" json:"custom_fields,omitempty"` // keyed by field key
 }
 
+// SubtaskSummary rolls up the direct children of a parent item.
+type SubtaskSummary struct {
+	Total           int     `json:"total"`
+	Done            int     `json:"done"`
+	PercentComplete float64 `json:"percent_complete"`
+	EstimateTotal   float64 `json:"estimate_total"`
+	EstimateDone    float64 `json:"estimate_done"`
+}
+
+// ItemStatus is a status key from the item's board workflow (see BoardStatus).
 type ItemStatus string
 
+// Status keys of the default workflow
 const (
 	StatusTodo       ItemStatus = "todo"
 	StatusInProgress ItemStatus = "in_progress"
@@ -32,4 +58,12 @@ const (
 	PriorityLow    ItemPriority = "low"
 	PriorityMedium ItemPriority = "medium"
 	PriorityHigh   ItemPriority = "high"
-)
\ No newline at end of file
+)
+
+// ChildDeletePolicy decides what happens to subtasks when their parent is deleted.
+type ChildDeletePolicy string
+
+const (
+	ChildDeleteCascade ChildDeletePolicy = "cascade" // delete the whole subtree
+	ChildDeleteOrphan  ChildDeletePolicy = "orphan"  // detach children, they become top-level items
+)
@@ -0,0 +1,53 @@
+package models
+
+import "gorm.io/gorm"
+
+// BoardTemplate is a snapshot of a board's setup that new boards can be created from.
+type BoardTemplate struct {
+	gorm.Model
+	UserID          uint          `gorm:"not null;index" json:"user_id"`
+	Name            string        `gorm:"size:100;not null" json:"name"`
+	Description     string        `gorm:"size:255" json:"description"`
+	EstimateUnit    EstimateUnit  `gorm:"type:varchar(10);default:'points'" json:"estimate_unit"`
+	EnforceBlockers bool          `gorm:"not null;default:false" json:"enforce_blockers"`
+	Snapshot        BoardSnapshot `gorm:"type:json;serializer:json" json:"snapshot"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// BoardSnapshot is everything a template copies onto a new board.
+type BoardSnapshot struct {
+	Statuses     []BoardStatus      `json:"statuses"`
+	Transitions  []StatusTransition `json:"transitions"`
+	CustomFields []CustomField      `json:"custom_fields"`
+	Items        []TemplateItem     `json:"items"` // parents always come before their subtasks
+}
+
+// TemplateItem is a starter item in a board template. Due dates are kept as an
+// offset from the template's earliest due date so they can be shifted.
+type TemplateItem struct {
+	Ref          uint                   `json:"ref"`                  // the source item's ID, used to rebuild subtasks
+	ParentRef    *uint                  `json:"parent_ref,omitempty"` // Ref of the parent item
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Status       ItemStatus             `json:"status"`
+	Priority     ItemPriority           `json:"priority"`
+	Estimate     *float64               `json:"estimate,omitempty"`
+	DueOffset    *int64                 `json:"due_offset_seconds,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// ItemTemplate pre-fills new items. Items have no checklist of their own, so the
+// checklist is added to the description as a markdown task list.
+type ItemTemplate struct {
+	gorm.Model
+	UserID      uint         `gorm:"not null;index" json:"user_id"`
+	BoardID     *uint        `gorm:"index" json:"board_id,omitempty"` // nil for templates usable on any board
+	Name        string       `gorm:"size:100;not null" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Checklist   []string     `gorm:"serializer:json" json:"checklist,omitempty"`
+	Priority    ItemPriority `gorm:"type:varchar(10)" json:"priority,omitempty"`
+
+	User  *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// SavedView is a named item filter and sort on a board. Query is in the item
+// query language and is compiled each time the view runs, so relative dates
+// such as due:<7d stay relative.
+type SavedView struct {
+	gorm.Model
+	BoardID uint   `gorm:"not null;index" json:"board_id"`
+	UserID  uint   `gorm:"not null;index" json:"user_id"` // owner, the only one who can change it
+	Name    string `gorm:"size:100;not null" json:"name"`
+	Query   string `gorm:"size:500" json:"query"`
+	Sort    string `gorm:"size:60" json:"sort"`
+	Shared  bool   `gorm:"not null;default:false" json:"shared"` // visible to everyone with access to the board
+
+	User  *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
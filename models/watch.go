@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Watch subscribes a user to an item or to a whole board (exactly one is set).
+// Events narrows what the user hears about; empty means their defaults.
+type Watch struct {
+	ID        uint         `gorm:"primarykey" json:"id"`
+	UserID    uint         `gorm:"not null;uniqueIndex:idx_watch_item;uniqueIndex:idx_watch_board" json:"user_id"`
+	ItemID    *uint        `gorm:"uniqueIndex:idx_watch_item" json:"item_id,omitempty"`
+	BoardID   *uint        `gorm:"uniqueIndex:idx_watch_board" json:"board_id,omitempty"`
+	Reason    WatchReason  `gorm:"type:varchar(20);default:'manual'" json:"reason"`
+	Events    []WatchEvent `gorm:"serializer:json" json:"events,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+
+	User  *User        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Item  *ProjectItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+	Board *Board       `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// WatchReason records why a user started watching. Items have no assignees
+// or comments yet, so creating an item or board is the only automatic reason.
+type WatchReason string
+
+const (
+	WatchManual  WatchReason = "manual"
+	WatchCreated WatchReason = "created"
+)
+
+// WatchEvent is a kind of change a watcher can subscribe to.
+type WatchEvent string
+
+const (
+	EventStatusChange WatchEvent = "status_change"
+	EventComment      WatchEvent = "comment"
+	EventDueDate      WatchEvent = "due_date"
+)
+
+// AllWatchEvents is what a user hears about until they choose otherwise.
+var AllWatchEvents = []WatchEvent{EventStatusChange, EventComment, EventDueDate}
+
+// SubscriptionDefaults are a user's event choices for watches without their own.
+type SubscriptionDefaults struct {
+	UserID    uint         `gorm:"primarykey;autoIncrement:false" json:"user_id"`
+	Events    []WatchEvent `gorm:"serializer:json" json:"events"`
+	UpdatedAt time.Time    `json:"updated_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BoardStatus is one step of a board's workflow. Items store the status Key.
+type BoardStatus struct {
+	gorm.Model
+	BoardID   uint           `gorm:"not null;uniqueIndex:idx_board_status_key" json:"board_id"`
+	Key       string         `gorm:"column:status_key;size:20;not null;uniqueIndex:idx_board_status_key" json:"key"`
+	Name      string         `gorm:"size:50;not null" json:"name"`
+	Category  StatusCategory `gorm:"type:varchar(20);not null" json:"category"`
+	Position  int            `gorm:"not null;default:0" json:"position"`
+	WIPLimit  *int           `gorm:"column:wip_limit" json:"wip_limit,omitempty"` // max items in this status, nil for no limit
+	WIPPolicy WIPPolicy      `gorm:"column:wip_policy;type:varchar(10);default:'soft'" json:"wip_policy"`
+
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+
+	// Computed on read, never stored
+	Load *int64 `gorm:"-" json:"load,omitempty"` // items currently in this status
+}
+
+// WIPPolicy decides what happens when a move would exceed a status' WIP limit.
+type WIPPolicy string
+
+const (
+	WIPSoft WIPPolicy = "soft" // allow the move and warn in the response
+	WIPHard WIPPolicy = "hard" // refuse the move unless a board admin overrides it
+)
+
+// WIPOverride is the audit record of a board admin pushing an item past a hard WIP limit.
+type WIPOverride struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	BoardID   uint      `gorm:"not null;index" json:"board_id"`
+	ItemID    uint      `gorm:"not null;index" json:"item_id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Status    string    `gorm:"size:20;not null" json:"status"`
+	Limit     int       `gorm:"column:wip_limit;not null" json:"limit"`
+	Load      int64     `gorm:"not null" json:"load"` // items in the status before the move
+	Reason    string    `gorm:"size:255;not null" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// StatusCategory groups statuses so features like blockers, roll-ups and
+// recurrence can tell "done" apart without knowing each board's status names.
+type StatusCategory string
+
+const (
+	CategoryNotStarted StatusCategory = "not_started"
+	CategoryActive     StatusCategory = "active"
+	CategoryComplete   StatusCategory = "complete"
+)
+
+// StatusTransition allows items on a board to move From -> To.
+// A board without transitions allows every move.
+type StatusTransition struct {
+	ID      uint   `gorm:"primarykey" json:"-"`
+	BoardID uint   `gorm:"not null;uniqueIndex:idx_board_transition" json:"-"`
+	From    string `gorm:"column:from_status;size:20;not null;uniqueIndex:idx_board_transition" json:"from"`
+	To      string `gorm:"column:to_status;size:20;not null;uniqueIndex:idx_board_transition" json:"to"`
+
+	Board *Board `gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// DefaultWorkflow is given to new boards and to boards created before workflows existed.
+var DefaultWorkflow = []BoardStatus{
+	{Key: string(StatusTodo), Name: "To Do", Category: CategoryNotStarted, Position: 0},
+	{Key: string(StatusInProgress), Name: "In Progress", Category: CategoryActive, Position: 1},
+	{Key: string(StatusDone), Name: "Done", Category: CategoryComplete, Position: 2},
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Worklog is time spent by a user on an item, either from a timer or entered by hand.
+// A running timer has no EndedAt yet.
+type Worklog struct {
+	gorm.Model
+	ItemID          uint       `gorm:"not null;index" json:"item_id"`
+	UserID          uint       `gorm:"not null;index" json:"user_id"`
+	StartedAt       time.Time  `gorm:"not null;index" json:"started_at"`
+	EndedAt         *time.Time `gorm:"index" json:"ended_at,omitempty"`
+	DurationSeconds int64      `gorm:"not null;default:0" json:"duration_seconds"`
+	Note            string     `gorm:"size:255" json:"note"`
+
+	Item *ProjectItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+	User *User        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,264 @@
+// Package openapi builds an OpenAPI 3.1 document from the routes registered on
+// a Fiber app and a table describing each of them.
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Auth is what a route expects in the Authorization header.
+type Auth int
+
+const (
+	AuthNone     Auth = iota
+	AuthRequired      // a bearer token is required
+	AuthOptional      // a bearer token is used when present
+)
+
+func (a Auth) String() string {
+	switch a {
+	case AuthRequired:
+		return "required"
+	case AuthOptional:
+		return "optional"
+	}
+	return "none"
+}
+
+// Param is a query or header parameter.
+type Param struct {
+	Name        string
+	Description string
+}
+
+// Operation documents one route. Request and Response are zero values of the
+// body types; a map such as a fiber.Map describes an object with one property
+// per key, typed after its value.
+type Operation struct {
+	Summary     string
+	Tag         string
+	Auth        Auth
+	Query       []Param
+	Headers     []Param // request headers
+	Request     interface{}
+	RequestType string      // media type of the request body, application/json when empty
+	Response    interface{} // nil when the route answers without a body
+	Status      int         // success status, 200 when zero
+}
+
+// Info is the document's info object.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema           `json:"schemas"`
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*body      `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type body struct {
+	Description string                        `json:"description,omitempty"`
+	Required    bool                          `json:"required,omitempty"`
+	Content     map[string]map[string]*Schema `json:"content,omitempty"`
+}
+
+func jsonContent(schema *Schema) map[string]map[string]*Schema {
+	return map[string]map[string]*Schema{"application/json": {"schema": schema}}
+}
+
+var (
+	pathParam     = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+	templateParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+)
+
+// Path turns a Fiber route path into an OpenAPI one: /items/:id/ becomes /items/{id}.
+func Path(route string) string {
+	if len(route) > 1 {
+		route = strings.TrimSuffix(route, "/")
+	}
+	return pathParam.ReplaceAllString(route, "{$1}")
+}
+
+// Key is how a route is looked up in the operations table, e.g. "GET /api/v1/items/{id}".
+func Key(method, route string) string {
+	return method + " " + Path(route)
+}
+
+// documentable lists the app's routes worth documenting, by key. HEAD routes
+// are left out; Fiber adds one for every GET.
+func documentable(routes []fiber.Route) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, r := range routes {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		key := Key(r.Method, r.Path)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Check compares the app's routes with the operations table. undocumented are
+// routes missing from the table, unused are table entries with no route.
+func Check(routes []fiber.Route, ops map[string]Operation) (undocumented, unused []string) {
+	registered := map[string]bool{}
+	for _, key := range documentable(routes) {
+		registered[key] = true
+		if _, ok := ops[key]; !ok {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for key := range ops {
+		if !registered[key] {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unused)
+	return undocumented, unused
+}
+
+// CheckAuth compares the auth each documented route expects with what authOf
+// reads from the route's handlers, and describes every route where they differ.
+func CheckAuth(routes []fiber.Route, ops map[string]Operation, authOf func(fiber.Route) Auth) (mismatched []string) {
+	for _, r := range routes {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		key := Key(r.Method, r.Path)
+		op, ok := ops[key]
+		if !ok {
+			continue
+		}
+		if actual := authOf(r); actual != op.Auth {
+			mismatched = append(mismatched, key+": documented "+op.Auth.String()+", route has "+actual.String())
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched
+}
+
+// Generate builds the document for the app's routes. Routes missing from ops
+// are still listed, with an "Undocumented" summary.
+func Generate(info Info, routes []fiber.Route, ops map[string]Operation) *Document {
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]map[string]string{
+				"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	requests := &schemaBuilder{components: doc.Components.Schemas, request: true}
+	responses := &schemaBuilder{components: doc.Components.Schemas}
+	problem := map[string]map[string]*Schema{apperr.ContentType: {"schema": responses.of(apperr.Problem{})}}
+
+	for _, key := range documentable(routes) {
+		method, path, _ := strings.Cut(key, " ")
+		op, ok := ops[key]
+		if !ok {
+			op = Operation{Summary: "Undocumented"}
+		}
+
+		out := &operation{
+			Summary:     op.Summary,
+			OperationID: operationID(method, path),
+			Responses: map[string]*body{
+				"default": {Description: "Error", Content: problem},
+			},
+		}
+		if op.Tag != "" {
+			out.Tags = []string{op.Tag}
+		}
+		for _, m := range templateParam.FindAllStringSubmatch(path, -1) {
+			schema := &Schema{Type: "string"}
+			if strings.HasSuffix(strings.ToLower(m[1]), "id") {
+				schema = &Schema{Type: "integer"}
+			}
+			out.Parameters = append(out.Parameters, parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+		}
+		for _, q := range op.Query {
+			out.Parameters = append(out.Parameters, parameter{Name: q.Name, In: "query", Description: q.Description, Schema: &Schema{Type: "string"}})
+		}
+		for _, h := range op.Headers {
+			out.Parameters = append(out.Parameters, parameter{Name: h.Name, In: "header", Description: h.Description, Schema: &Schema{Type: "string"}})
+		}
+		if op.Request != nil {
+			requestType := op.RequestType
+			if requestType == "" {
+				requestType = "application/json"
+			}
+			out.RequestBody = &body{Required: true, Content: map[string]map[string]*Schema{requestType: {"schema": requests.of(op.Request)}}}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &body{Description: http.StatusText(status)}
+		if op.Response != nil {
+			success.Content = jsonContent(responses.of(op.Response))
+		}
+		out.Responses[strconv.Itoa(status)] = success
+
+		switch op.Auth {
+		case AuthRequired:
+			out.Security = []map[string][]string{{"bearerAuth": {}}}
+		case AuthOptional:
+			out.Security = []map[string][]string{{}, {"bearerAuth": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = out
+	}
+	return doc
+}
+
+// operationID derives a stable ID such as get_api_v1_items_id.
+func operationID(method, path string) string {
+	id := strings.ToLower(method) + strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_").Replace(path)
+	return strings.TrimSuffix(id, "_")
+}
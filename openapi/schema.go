@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"` // a type name, or a list of them
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaBuilder turns Go values into schemas. Named structs become components
+// and are referenced by name.
+type schemaBuilder struct {
+	components map[string]*Schema
+	request    bool // building a request body: only validate:"required" fields are required
+}
+
+func (b *schemaBuilder) of(v interface{}) *Schema {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Type().Elem().Kind() == reflect.Interface {
+		return b.object(rv)
+	}
+	return b.forType(reflect.TypeOf(v))
+}
+
+// object describes a map literal such as a fiber.Map: one optional property
+// per key, typed after its value.
+func (b *schemaBuilder) object(m reflect.Value) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	iter := m.MapRange()
+	for iter.Next() {
+		if iter.Value().IsNil() {
+			s.Properties[iter.Key().String()] = &Schema{}
+			continue
+		}
+		s.Properties[iter.Key().String()] = b.of(iter.Value().Interface())
+	}
+	return s
+}
+
+func nullable(s *Schema) *Schema {
+	switch t := s.Type.(type) {
+	case string:
+		s.Type = []string{t, "null"}
+		return s
+	case nil:
+		if s.Ref == "" {
+			return s // any already allows null
+		}
+	}
+	return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+}
+
+func (b *schemaBuilder) forType(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Pointer {
+		return nullable(b.forType(t.Elem()))
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		// Types with their own JSON encoding: a nullable time such as
+		// gorm.DeletedAt, or anything else we can't see into.
+		if t.Kind() != reflect.Struct {
+			return &Schema{}
+		}
+		if f, ok := t.FieldByName("Time"); ok && f.Type == timeType {
+			return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+		}
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.forType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.forType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.components[name]; !ok {
+			b.components[name] = &Schema{} // placeholder, for self-referencing types
+			b.components[name] = b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// structSchema lists a struct's JSON fields, flattening embedded structs the
+// way encoding/json does.
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = b.forType(f.Type)
+			if b.isRequired(f, opts) {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	walk(t)
+	return s
+}
+
+// isRequired reports whether a field is always present: in a request, when it
+// is validated as required; in a response, when it is never omitted.
+func (b *schemaBuilder) isRequired(f reflect.StructField, jsonOpts string) bool {
+	if b.request {
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+		return false
+	}
+	return !strings.Contains(jsonOpts, "omitempty") && f.Type.Kind() != reflect.Pointer
+}
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// UI is a self-contained API explorer page. It reads the document from
+// /openapi.json and can send requests with a bearer token.
+//
+//go:embed ui.html
+var UI []byte
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterDocsRoutes(app *fiber.App) {
+	app.Get("/openapi.json", services.GetOpenAPI)
+	app.Get("/docs", services.GetAPIDocs)
+
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterHomeRoutes(app *fiber.App) {
+	api := app.Group("api/v1/home", middleware.AuthMiddleware())
+
+	api.Get("/", services.GetHome)
+	api.Put("/starred", services.ReorderStarredBoards)
+
+}
@@ -9,11 +9,34 @@ import (
 func RegisterBoardoutes(app *fiber.App) {
 	api := app.Group("api/v1/boards")
 
-	api.Post("/", middleware.AuthMiddleware(), middleware.ValidateBody(), services.CreateBoard)
+	api.Post("/", middleware.AuthMiddleware(), services.Idempotency, middleware.ValidateBody[services.BoardRequest](), services.CreateBoard)
 	api.Get("/", services.GetBoards)
-	api.Get("/:id", services.GetBoardByID)
-	api.Put("/:id", services.UpdateBoard)
+	api.Get("/:id", middleware.OptionalAuthMiddleware(), services.GetBoardByID)
+	api.Put("/:id", middleware.AuthMiddleware(), services.UpdateBoard)
+	api.Patch("/:id", middleware.AuthMiddleware(), services.PatchBoard)
 	api.Get("/user/:id", services.GetBoardByUserID)
-	api.Delete("/:id", services.DeleteBoard)
+	api.Delete("/:id", middleware.AuthMiddleware(), services.DeleteBoard)
+	api.Get("/:id/fields", services.GetBoardCustomFields)
+	api.Post("/:id/fields", middleware.AuthMiddleware(), services.Idempotency, services.CreateBoardCustomField)
+	api.Put("/:id/fields/:fieldId", middleware.AuthMiddleware(), services.UpdateBoardCustomField)
+	api.Delete("/:id/fields/:fieldId", middleware.AuthMiddleware(), services.DeleteBoardCustomField)
+	api.Get("/:id/workflow", services.GetBoardWorkflow)
+	api.Put("/:id/workflow", middleware.AuthMiddleware(), services.UpdateBoardWorkflow)
+	api.Get("/:id/wip-overrides", middleware.AuthMiddleware(), services.GetBoardWIPOverrides)
+	api.Post("/:id/watch", middleware.AuthMiddleware(), services.WatchBoard)
+	api.Delete("/:id/watch", middleware.AuthMiddleware(), services.UnwatchBoard)
+	api.Post("/:id/duplicate", middleware.AuthMiddleware(), services.Idempotency, services.DuplicateBoard)
+	api.Post("/:id/archive", middleware.AuthMiddleware(), services.ArchiveBoard)
+	api.Post("/:id/unarchive", middleware.AuthMiddleware(), services.UnarchiveBoard)
+	api.Post("/:id/star", middleware.AuthMiddleware(), services.StarBoard)
+	api.Delete("/:id/star", middleware.AuthMiddleware(), services.UnstarBoard)
+	api.Get("/:id/views", middleware.AuthMiddleware(), services.GetBoardSavedViews)
+	api.Post("/:id/views", middleware.AuthMiddleware(), services.Idempotency, services.CreateSavedView)
+	api.Put("/:id/default-view", middleware.AuthMiddleware(), services.SetBoardDefaultView)
+	api.Get("/:id/default-view/items", middleware.AuthMiddleware(), services.RunBoardDefaultView)
+	api.Get("/:id/members", middleware.AuthMiddleware(), services.GetBoardMembers)
+	api.Post("/:id/members", middleware.AuthMiddleware(), services.Idempotency, services.AddBoardMember)
+	api.Put("/:id/members/:userId", middleware.AuthMiddleware(), services.UpdateBoardMember)
+	api.Delete("/:id/members/:userId", middleware.AuthMiddleware(), services.RemoveBoardMember)
 
 }
@@ -10,11 +10,26 @@ import (
 func RegisterProjectItemsRoutes(app *fiber.App) {
 	api := app.Group("api/v1/items")
 
-	api.Post("/", middleware.AuthMiddleware(), middleware.ValidateBody(), services.CreateProjectItem)
+	api.Post("/", middleware.AuthMiddleware(), services.Idempotency, middleware.ValidateBody[services.ItemRequestPayload](), services.CreateProjectItem)
+	api.Post("/bulk", middleware.AuthMiddleware(), services.BulkUpdateProjectItems)
 	api.Get("/", services.GetProjectItems)
-	api.Get("/:id", services.GetProjectItemByID)
-	api.Put("/:id", services.UpdateProjectItem)
+	api.Get("/:id", middleware.OptionalAuthMiddleware(), services.GetProjectItemByID)
+	api.Put("/:id", middleware.AuthMiddleware(), services.UpdateProjectItem)
+	api.Patch("/:id", middleware.AuthMiddleware(), services.PatchProjectItem)
 	api.Get("/board/:id", services.GetProjectItemsByBoardID)
-	api.Delete("/:id", services.DeleteProjectItem)
+	api.Get("/:id/children", services.GetProjectItemChildren)
+	api.Post("/:id/dependencies", middleware.AuthMiddleware(), services.Idempotency, services.AddItemDependency)
+	api.Delete("/:id/dependencies/:blockerId", middleware.AuthMiddleware(), services.RemoveItemDependency)
+	api.Get("/:id/recurrence", middleware.AuthMiddleware(), services.GetItemRecurrence)
+	api.Put("/:id/recurrence", middleware.AuthMiddleware(), services.SetItemRecurrence)
+	api.Delete("/:id/recurrence", middleware.AuthMiddleware(), services.StopItemRecurrence)
+	api.Get("/:id/worklogs", middleware.AuthMiddleware(), services.GetItemWorklogs)
+	api.Post("/:id/watch", middleware.AuthMiddleware(), services.WatchItem)
+	api.Delete("/:id/watch", middleware.AuthMiddleware(), services.UnwatchItem)
+	api.Post("/:id/archive", middleware.AuthMiddleware(), services.ArchiveProjectItem)
+	api.Post("/:id/unarchive", middleware.AuthMiddleware(), services.UnarchiveProjectItem)
+	api.Post("/:id/move", middleware.AuthMiddleware(), services.MoveProjectItem)
+	api.Post("/:id/copy", middleware.AuthMiddleware(), services.Idempotency, services.CopyProjectItem)
+	api.Delete("/:id", middleware.AuthMiddleware(), services.DeleteProjectItem)
 
 }
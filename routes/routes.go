@@ -0,0 +1,18 @@
+package routes
+
+import "github.com/gofiber/fiber/v2"
+
+// RegisterRoutes registers every API route on the app.
+func RegisterRoutes(app *fiber.App) {
+	RegisterAuthorRoutes(app)
+	RegisterBoardoutes(app)
+	RegisterProjectItemsRoutes(app)
+	RegisterWorklogRoutes(app)
+	RegisterSubscriptionRoutes(app)
+	RegisterTemplateRoutes(app)
+	RegisterTrashRoutes(app)
+	RegisterHomeRoutes(app)
+	RegisterSearchRoutes(app)
+	RegisterViewRoutes(app)
+	RegisterDocsRoutes(app)
+}
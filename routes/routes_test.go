@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/openapi"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Every route needs an entry in the API documentation (services/openapi.go),
+// and every entry needs a route.
+func TestRoutesAreDocumented(t *testing.T) {
+	app := fiber.New()
+	RegisterRoutes(app)
+
+	undocumented, unused := services.CheckAPIDocs(app)
+	for _, key := range undocumented {
+		t.Errorf("route %s is not documented in services/openapi.go", key)
+	}
+	for _, key := range unused {
+		t.Errorf("services/openapi.go documents %s, but no such route is registered", key)
+	}
+}
+
+// The documented auth of every route must match the auth middleware it runs.
+func TestRouteAuthIsDocumented(t *testing.T) {
+	app := fiber.New()
+	RegisterRoutes(app)
+
+	for _, mismatch := range services.CheckAPIAuth(app, authReader(app)) {
+		t.Errorf("services/openapi.go: %s", mismatch)
+	}
+}
+
+// authReader tells a route's auth from the auth middleware it runs: its own
+// handlers, or a group's, which Fiber registers as a lone-handler route on the
+// group's prefix. Every AuthMiddleware() is the same closure, so its code
+// pointer identifies it.
+func authReader(app *fiber.App) func(fiber.Route) openapi.Auth {
+	required := handlerPointer(middleware.AuthMiddleware())
+	optional := handlerPointer(middleware.OptionalAuthMiddleware())
+	authOf := func(h fiber.Handler) openapi.Auth {
+		switch handlerPointer(h) {
+		case required:
+			return openapi.AuthRequired
+		case optional:
+			return openapi.AuthOptional
+		}
+		return openapi.AuthNone
+	}
+
+	groups := map[string]openapi.Auth{}
+	for _, r := range app.GetRoutes() {
+		if len(r.Handlers) == 1 {
+			if auth := authOf(r.Handlers[0]); auth != openapi.AuthNone {
+				groups[r.Path] = auth
+			}
+		}
+	}
+
+	return func(r fiber.Route) openapi.Auth {
+		for prefix, auth := range groups {
+			if r.Path == prefix || strings.HasPrefix(r.Path, prefix+"/") {
+				return auth
+			}
+		}
+		for _, h := range r.Handlers {
+			if auth := authOf(h); auth != openapi.AuthNone {
+				return auth
+			}
+		}
+		return openapi.AuthNone
+	}
+}
+
+func handlerPointer(h fiber.Handler) uintptr {
+	return reflect.ValueOf(h).Pointer()
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterSearchRoutes(app *fiber.App) {
+	api := app.Group("api/v1/search", middleware.AuthMiddleware())
+
+	api.Get("/", services.Search)
+
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterSubscriptionRoutes(app *fiber.App) {
+	api := app.Group("api/v1/subscriptions", middleware.AuthMiddleware())
+
+	api.Get("/", services.GetSubscriptions)
+	api.Put("/defaults", services.UpdateSubscriptionDefaults)
+	api.Put("/:id", services.UpdateWatchEvents)
+
+}
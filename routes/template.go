@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterTemplateRoutes(app *fiber.App) {
+	api := app.Group("api/v1/templates", middleware.AuthMiddleware())
+
+	api.Post("/boards", services.Idempotency, services.CreateBoardTemplate)
+	api.Get("/boards", services.GetBoardTemplates)
+	api.Get("/boards/:id", services.GetBoardTemplate)
+	api.Delete("/boards/:id", services.DeleteBoardTemplate)
+	api.Post("/boards/:id/instantiate", services.Idempotency, services.InstantiateBoardTemplate)
+
+	api.Post("/items", services.Idempotency, services.CreateItemTemplate)
+	api.Get("/items", services.GetItemTemplates)
+	api.Delete("/items/:id", services.DeleteItemTemplate)
+
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterTrashRoutes(app *fiber.App) {
+	api := app.Group("api/v1/trash", middleware.AuthMiddleware())
+
+	api.Get("/", services.GetTrash)
+	api.Post("/boards/:id/restore", services.RestoreBoard)
+	api.Delete("/boards/:id", services.PurgeBoard)
+	api.Post("/items/:id/restore", services.RestoreProjectItem)
+	api.Delete("/items/:id", services.PurgeProjectItem)
+
+}
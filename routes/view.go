@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterViewRoutes(app *fiber.App) {
+	api := app.Group("api/v1/views", middleware.AuthMiddleware())
+
+	api.Get("/:id", services.GetSavedView)
+	api.Put("/:id", services.UpdateSavedView)
+	api.Delete("/:id", services.DeleteSavedView)
+	api.Get("/:id/items", services.RunSavedView)
+
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/clem-kay/mini-trello/middleware"
+	"github.com/clem-kay/mini-trello/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterWorklogRoutes(app *fiber.App) {
+	api := app.Group("api/v1/worklogs", middleware.AuthMiddleware())
+
+	api.Post("/", services.Idempotency, services.CreateWorklog)
+	api.Delete("/:id", services.DeleteWorklog)
+	api.Get("/timer", services.GetRunningTimer)
+	api.Post("/timer/start", services.Idempotency, services.StartTimer)
+	api.Post("/timer/stop", services.StopTimer)
+	api.Get("/report", services.GetWorklogReport)
+
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+)
+
+// canAccessBoard reports whether the user may read and edit the board: its
+// owner and its members.
+func canAccessBoard(userID, boardID uint) bool {
+	return boardAllows(userID, boardID, models.MemberRoleMember, models.MemberRoleAdmin)
+}
+
+// accessibleBoardIDs returns the boards the user may read and edit.
+func accessibleBoardIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := config.DB.Model(&models.Board{}).Scopes(accessibleBoards(userID)).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// accessibleBoards limits a boards query to the ones the user may read and edit.
+func accessibleBoards(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("boards.user_id = ? OR boards.id IN (?)", userID, memberBoards(userID, models.MemberRoleMember, models.MemberRoleAdmin))
+	}
+}
+
+// canAdminBoard reports whether the user may change the board's configuration:
+// its owner and its admins. A user's own role says nothing about a board.
+func canAdminBoard(userID, boardID uint) bool {
+	return boardAllows(userID, boardID, models.MemberRoleAdmin)
+}
+
+// boardAllows reports whether the user owns the board or is a member of it
+// with one of roles.
+func boardAllows(userID, boardID uint, roles ...models.MemberRole) bool {
+	if userID == 0 {
+		return false
+	}
+	var count int64
+	config.DB.Model(&models.Board{}).
+		Where("id = ? AND (user_id = ? OR id IN (?))", boardID, userID, memberBoards(userID, roles...)).
+		Count(&count)
+	return count > 0
+}
+
+// memberBoards selects the IDs of the boards the user is a member of with one
+// of roles, for use as a subquery.
+func memberBoards(userID uint, roles ...models.MemberRole) *gorm.DB {
+	return config.DB.Model(&models.BoardMember{}).Select("board_id").Where("user_id = ? AND role IN ?", userID, roles)
+}
@@ -1,9 +1,9 @@
 package services
 
 import (
-	"fmt"
 	"strings"
 
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/clem-kay/mini-trello/config"
 	"github.com/clem-kay/mini-trello/models"
 	"github.com/clem-kay/mini-trello/utils"
@@ -16,33 +16,34 @@ type RegisterRequest struct {
 	LastName  string `json:"last_name" validate:"required"`
 	Email     string `json:"email" validate:"required,email"`
 	Password  string `json:"password" validate:"required,min=6"`
-	Role	  string `json:"role`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	Role     string `json:"role"`
 }
 
 func Login(c *fiber.Ctx) error {
-	var body struct {
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required"`
-		Role     string `json:"role"`
-	}
+	var body LoginRequest
 
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return apperr.BadRequest("Cannot parse JSON")
 	}
 
 	var user models.User
 	if err := config.DB.Where("email = ?", body.Email).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		return apperr.Unauthorized("Invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(body.Password)); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		return apperr.Unauthorized("Invalid credentials")
 	}
 
 	// ✅ Generate JWT
 	token, err := utils.GenerateJWT(user.ID, user.Email)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not generate token"})
+		return apperr.Internal("Could not generate token", err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -54,28 +55,26 @@ func Login(c *fiber.Ctx) error {
 func Register(c *fiber.Ctx) error {
 	var body RegisterRequest
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cannot parse JSON",
-		})
+		return apperr.BadRequest("Cannot parse JSON")
 	}
 
 	if body.FirstName == "" || body.LastName == "" || body.Email == "" || body.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "first_name, last_name, email, password fields are required",
-		})
+		var fields []apperr.FieldError
+		for _, f := range [][2]string{{"first_name", body.FirstName}, {"last_name", body.LastName}, {"email", body.Email}, {"password", body.Password}} {
+			if f[1] == "" {
+				fields = append(fields, apperr.Field(f[0], "is required"))
+			}
+		}
+		return apperr.Invalid("first_name, last_name, email, password fields are required", fields...)
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to hash password",
-		})
+		return apperr.Internal("Failed to hash password", err)
 	}
 
+	// Everyone signs up as a plain user; the role is not the client's to pick
 	userRole := "user"
-	if body.Role != ""{
-		userRole = body.Role
-	}
 
 	user := models.User{
 		FirstName: body.FirstName,
@@ -89,15 +88,11 @@ func Register(c *fiber.Ctx) error {
 
 	if result.Error != nil {
 		if strings.Contains(result.Error.Error(), "Duplicate entry") {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "Email already exists",
-			})
+			return apperr.Conflict("Email already exists").WithCode(codeEmailTaken)
 		}
 
 		// fallback for other DB errors
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Could not create user: %v", result.Error),
-		})
+		return apperr.Internal("Could not create user", result.Error)
 	}
 	response := fiber.Map{
 		"id":         user.ID,
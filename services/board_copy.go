@@ -0,0 +1,260 @@
+package services
+
+import (
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// duplicateBoardRequest controls what a board copy brings along. The board's
+// workflow and custom field definitions are always copied.
+type duplicateBoardRequest struct {
+	Name                string `json:"title" validate:"omitempty,min=3,max=100"` // defaults to "Copy of <name>"
+	IncludeItems        *bool  `json:"include_items"`                            // defaults to true
+	IncludeDependencies *bool  `json:"include_dependencies"`                     // blockers between copied items, defaults to true
+	IncludeMembers      bool   `json:"include_members"`                          // copy the board's members and their watches
+}
+
+func boolOr(v *bool, fallback bool) bool {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// copyName is the default name of a board copy, cut to the 100 characters a
+// board name may have.
+func copyName(name string) string {
+	name = "Copy of " + name
+	if utf8.RuneCountInString(name) > 100 {
+		name = string([]rune(name)[:100])
+	}
+	return name
+}
+
+// copyBoard deep-copies a board inside tx and gives the copy to ownerID.
+func copyBoard(tx *gorm.DB, src *models.Board, ownerID uint, name string, opts duplicateBoardRequest) (*models.Board, error) {
+	board := models.Board{
+		Name:            name,
+		Description:     src.Description,
+		UserID:          ownerID,
+		EstimateUnit:    src.EstimateUnit,
+		EnforceBlockers: src.EnforceBlockers,
+	}
+	if err := tx.Create(&board).Error; err != nil {
+		return nil, err
+	}
+	if err := watchBoard(tx, ownerID, board.ID, models.WatchCreated); err != nil {
+		return nil, err
+	}
+
+	statuses, transitions, err := loadWorkflow(tx, src.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statuses {
+		copied := models.BoardStatus{
+			BoardID:   board.ID,
+			Key:       s.Key,
+			Name:      s.Name,
+			Category:  s.Category,
+			Position:  s.Position,
+			WIPLimit:  s.WIPLimit,
+			WIPPolicy: s.WIPPolicy,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range transitions {
+		if err := tx.Create(&models.StatusTransition{BoardID: board.ID, From: t.From, To: t.To}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var fields []models.CustomField
+	if err := tx.Where("board_id = ?", src.ID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	fieldIDs := make(map[uint]uint, len(fields))
+	for _, f := range fields {
+		copied := f
+		copied.Model = gorm.Model{}
+		copied.BoardID = board.ID
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		fieldIDs[f.ID] = copied.ID
+	}
+
+	// Watchers only come along with their access to the board
+	var watchers map[uint]bool
+	if opts.IncludeMembers {
+		watchers = map[uint]bool{ownerID: true}
+		var members []models.BoardMember
+		if err := tx.Where("board_id = ? AND user_id <> ?", src.ID, ownerID).Find(&members).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if err := tx.Create(&models.BoardMember{BoardID: board.ID, UserID: m.UserID, Role: m.Role}).Error; err != nil {
+				return nil, err
+			}
+			watchers[m.UserID] = true
+		}
+
+		var watches []models.Watch
+		if err := tx.Where("board_id = ? AND user_id <> ?", src.ID, ownerID).Find(&watches).Error; err != nil {
+			return nil, err
+		}
+		for _, w := range watches {
+			if !watchers[w.UserID] {
+				continue
+			}
+			if err := tx.Create(&models.Watch{UserID: w.UserID, BoardID: &board.ID, Reason: w.Reason, Events: w.Events}).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !boolOr(opts.IncludeItems, true) {
+		return &board, nil
+	}
+
+	var items []models.ProjectItem
+	if err := tx.Where("board_id = ?", src.ID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	itemIDs := make(map[uint]uint, len(items))
+	for _, item := range sortParentsFirst(items) {
+		copied := models.ProjectItem{
+			Name:        item.Name,
+			BoardID:     board.ID,
+			Description: item.Description,
+			DueDate:     item.DueDate,
+			Status:      item.Status,
+			Priority:    item.Priority,
+			Estimate:    item.Estimate,
+			ArchivedAt:  item.ArchivedAt,
+		}
+		if item.ParentID != nil {
+			if id, ok := itemIDs[*item.ParentID]; ok {
+				copied.ParentID = &id
+			}
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		itemIDs[item.ID] = copied.ID
+	}
+
+	if err := copyItemRecords(tx, itemIDs, fieldIDs, boolOr(opts.IncludeDependencies, true), watchers); err != nil {
+		return nil, err
+	}
+	return &board, nil
+}
+
+// copyItemRecords copies the records hanging off items onto their copies.
+// itemIDs maps source item IDs to copied item IDs; fieldIDs does the same for
+// custom fields, and values of fields missing from it are dropped. Only the
+// watches of users in watchers are copied.
+func copyItemRecords(tx *gorm.DB, itemIDs, fieldIDs map[uint]uint, dependencies bool, watchers map[uint]bool) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+	sourceIDs := make([]uint, 0, len(itemIDs))
+	for id := range itemIDs {
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	var values []models.CustomFieldValue
+	if err := tx.Where("item_id IN ?", sourceIDs).Find(&values).Error; err != nil {
+		return err
+	}
+	for _, v := range values {
+		fieldID, ok := fieldIDs[v.FieldID]
+		if !ok {
+			continue
+		}
+		copied := v
+		copied.ID = 0
+		copied.ItemID = itemIDs[v.ItemID]
+		copied.FieldID = fieldID
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	if dependencies {
+		// Only blockers where both ends were copied
+		var deps []models.ItemDependency
+		if err := tx.Where("blocker_id IN ? AND blocked_id IN ?", sourceIDs, sourceIDs).Find(&deps).Error; err != nil {
+			return err
+		}
+		for _, d := range deps {
+			if err := tx.Create(&models.ItemDependency{BlockerID: itemIDs[d.BlockerID], BlockedID: itemIDs[d.BlockedID]}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(watchers) > 0 {
+		var watches []models.Watch
+		if err := tx.Where("item_id IN ?", sourceIDs).Find(&watches).Error; err != nil {
+			return err
+		}
+		for _, w := range watches {
+			if !watchers[w.UserID] {
+				continue
+			}
+			id := itemIDs[*w.ItemID]
+			if err := tx.Create(&models.Watch{UserID: w.UserID, ItemID: &id, Reason: w.Reason, Events: w.Events}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ✅ Duplicate a board; the copy belongs to the caller
+func DuplicateBoard(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var src models.Board
+	if err := config.DB.First(&src, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, src.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	var body duplicateBoardRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return apperr.BadRequest("Cannot parse JSON")
+		}
+	}
+	name := body.Name
+	if name == "" {
+		name = copyName(src.Name)
+	}
+
+	var board *models.Board
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		board, err = copyBoard(tx, &src, currentUserID, name, body)
+		return err
+	})
+	if err != nil {
+		return apperr.Internal("Could not duplicate board", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(board)
+}
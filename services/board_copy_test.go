@@ -0,0 +1,41 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCopyName(t *testing.T) {
+	if got := copyName("Roadmap"); got != "Copy of Roadmap" {
+		t.Errorf("copyName(Roadmap) = %q", got)
+	}
+	// Board names are counted in characters, and a cut must not split one
+	got := copyName(strings.Repeat("ü", 100))
+	if !utf8.ValidString(got) || utf8.RuneCountInString(got) != 100 {
+		t.Errorf("copyName of a 100 character name = %q (%d characters)", got, utf8.RuneCountInString(got))
+	}
+}
+
+// Watches are only copied for users who can see the copy.
+func TestCopyItemRecordsWatchers(t *testing.T) {
+	db, fake := openFakeDB(t)
+	fake.answer([]string{"id"}) // no custom field values
+	fake.answer([]string{"id", "user_id", "item_id", "reason"},
+		[]driver.Value{int64(1), int64(5), int64(10), "manual"},
+		[]driver.Value{int64(2), int64(6), int64(10), "manual"})
+
+	if err := copyItemRecords(db, map[uint]uint{10: 20}, nil, false, map[uint]bool{5: true}); err != nil {
+		t.Fatal(err)
+	}
+	var inserts []string
+	for _, statement := range fake.sent() {
+		if strings.HasPrefix(statement, "INSERT") {
+			inserts = append(inserts, statement)
+		}
+	}
+	if len(inserts) != 1 || !strings.Contains(inserts[0], "(5,20,") {
+		t.Errorf("inserts = %q, want only user 5's watch on item 20", inserts)
+	}
+}
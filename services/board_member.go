@@ -0,0 +1,192 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm/clause"
+)
+
+type boardMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"omitempty,oneof=member admin"` // defaults to member
+}
+
+type memberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=member admin"`
+}
+
+// boardMember is an entry in a board's member list.
+type boardMember struct {
+	UserID    uint   `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"` // owner, admin or member
+}
+
+// parseMemberRole reads a role from a request; empty means member.
+func parseMemberRole(role string) (models.MemberRole, bool) {
+	switch models.MemberRole(role) {
+	case "", models.MemberRoleMember:
+		return models.MemberRoleMember, true
+	case models.MemberRoleAdmin:
+		return models.MemberRoleAdmin, true
+	}
+	return "", false
+}
+
+// loadBoardMember fetches the member in the :userId param of the board. On
+// failure it returns nil and the error to respond with.
+func loadBoardMember(c *fiber.Ctx, boardID uint) (*models.BoardMember, error) {
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 64)
+	if err != nil {
+		return nil, apperr.Invalid("Invalid user ID format")
+	}
+	var member models.BoardMember
+	if err := config.DB.Where("board_id = ? AND user_id = ?", boardID, uint(userID)).First(&member).Error; err != nil {
+		return nil, apperr.NotFound("Member not found")
+	}
+	return &member, nil
+}
+
+// ✅ List who can access a board: the owner first, then the members
+func GetBoardMembers(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	var members []boardMember
+	err := config.DB.Model(&models.User{}).
+		Select("users.id AS user_id, users.first_name, users.last_name, users.email, 'owner' AS role").
+		Where("users.id = ?", board.UserID).
+		Scan(&members).Error
+	if err != nil {
+		return apperr.Internal("Could not fetch members", err)
+	}
+	var others []boardMember
+	err = config.DB.Model(&models.BoardMember{}).
+		Select("users.id AS user_id, users.first_name, users.last_name, users.email, board_members.role").
+		Joins("JOIN users ON users.id = board_members.user_id AND users.deleted_at IS NULL").
+		Where("board_members.board_id = ?", board.ID).
+		Order("board_members.id").
+		Scan(&others).Error
+	if err != nil {
+		return apperr.Internal("Could not fetch members", err)
+	}
+
+	return c.JSON(append(members, others...))
+}
+
+// ✅ Add a user to a board by email (board admins only)
+func AddBoardMember(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var body boardMemberRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	role, ok := parseMemberRole(body.Role)
+	if !ok {
+		return apperr.Invalid("role must be member or admin", apperr.Field("role", "must be member or admin"))
+	}
+	if body.Email == "" {
+		return apperr.Invalid("email is required", apperr.Field("email", "is required"))
+	}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", body.Email).First(&user).Error; err != nil {
+		return apperr.NotFound("No user with that email")
+	}
+	if user.ID == board.UserID {
+		return apperr.Conflict("The board's owner is already on it").WithCode(codeMemberExists)
+	}
+
+	member := models.BoardMember{BoardID: board.ID, UserID: user.ID, Role: role}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+	if result.Error != nil {
+		return apperr.Internal("Could not add member", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.Conflict("The user is already a member of this board").WithCode(codeMemberExists)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(boardMember{
+		UserID:    user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Role:      string(role),
+	})
+}
+
+// ✅ Change a member's role (board admins only)
+func UpdateBoardMember(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+	member, err := loadBoardMember(c, board.ID)
+	if member == nil {
+		return err
+	}
+
+	var body memberRoleRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	role, ok := parseMemberRole(body.Role)
+	if !ok || body.Role == "" {
+		return apperr.Invalid("role must be member or admin", apperr.Field("role", "must be member or admin"))
+	}
+
+	if err := config.DB.Model(member).Update("role", role).Error; err != nil {
+		return apperr.Internal("Could not update member", err)
+	}
+	return c.JSON(member)
+}
+
+// ✅ Remove a member from a board: board admins remove anyone, members remove
+// themselves
+func RemoveBoardMember(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+	member, err := loadBoardMember(c, board.ID)
+	if member == nil {
+		return err
+	}
+	if member.UserID != currentUserID && !canAdminBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("Only board admins can remove other members")
+	}
+
+	if err := config.DB.Delete(member).Error; err != nil {
+		return apperr.Internal("Could not remove member", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
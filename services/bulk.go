@@ -0,0 +1,412 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// How many items one bulk request may touch
+var bulkMaxItems = parseEnvInt("BULK_MAX_ITEMS", 500)
+
+// errBulkAborted rolls back an all_or_nothing run after an item failed.
+var errBulkAborted = errors.New("bulk operation aborted")
+
+const (
+	bulkAllOrNothing = "all_or_nothing"
+	bulkBestEffort   = "best_effort"
+)
+
+type bulkItemsRequest struct {
+	IDs       []uint          `json:"ids"`       // either ids or filter
+	Filter    *bulkItemFilter `json:"filter"`    // matches items on boards the caller can access
+	Operation string          `json:"operation"` // status, priority, due_date, move or delete
+	Value     json.RawMessage `json:"value"`     // status key, priority, RFC3339 due date (null clears) or target board ID
+	Mode      string          `json:"mode"`      // all_or_nothing (default) or best_effort
+}
+
+type bulkItemFilter struct {
+	BoardID  uint     `json:"board_id"`
+	Status   []string `json:"status"`
+	Priority []string `json:"priority"`
+}
+
+// bulkItemError is why one item failed, told to the client in its result.
+// Any other error from apply is the server's and fails the whole request.
+type bulkItemError string
+
+func (e bulkItemError) Error() string { return string(e) }
+
+// bulkResult is the outcome for one item.
+type bulkResult struct {
+	ID      uint   `json:"id"`
+	Result  string `json:"result"` // ok, failed, or rolled_back when an all_or_nothing run failed elsewhere
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// bulkOperation is a parsed bulk request ready to apply to items.
+type bulkOperation struct {
+	userID    uint
+	operation string
+	status    models.ItemStatus
+	priority  models.ItemPriority
+	dueDate   *time.Time
+	target    *models.Board
+	transfers map[uint]*itemTransfer // by source board
+}
+
+func parseBulkOperation(req *bulkItemsRequest, userID uint) (*bulkOperation, error) {
+	op := &bulkOperation{userID: userID, operation: req.Operation}
+	switch req.Operation {
+	case "status":
+		var s string
+		if err := json.Unmarshal(req.Value, &s); err != nil || s == "" {
+			return nil, errors.New("value must be a status key")
+		}
+		op.status = models.ItemStatus(s)
+	case "priority":
+		var p string
+		if err := json.Unmarshal(req.Value, &p); err != nil {
+			return nil, errors.New("value must be low, medium or high")
+		}
+		switch models.ItemPriority(p) {
+		case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+			op.priority = models.ItemPriority(p)
+		default:
+			return nil, errors.New("value must be low, medium or high")
+		}
+	case "due_date":
+		var s *string
+		if err := json.Unmarshal(req.Value, &s); err != nil {
+			return nil, errors.New("value must be an RFC3339 date or null")
+		}
+		if s != nil {
+			parsed, err := time.Parse(time.RFC3339, *s)
+			if err != nil {
+				return nil, errors.New("value must be an RFC3339 date or null")
+			}
+			op.dueDate = &parsed
+		}
+	case "move":
+		var boardID uint
+		if err := json.Unmarshal(req.Value, &boardID); err != nil || boardID == 0 {
+			return nil, errors.New("value must be the target board ID")
+		}
+		var board models.Board
+		if err := config.DB.First(&board, boardID).Error; err != nil {
+			return nil, errors.New("Target board not found")
+		}
+		if !canAccessBoard(userID, board.ID) {
+			return nil, errors.New("You do not have access to the target board")
+		}
+		op.target = &board
+		op.transfers = map[uint]*itemTransfer{}
+	case "delete":
+	case "assignee", "label":
+		return nil, fmt.Errorf("Items have no %ss yet", req.Operation)
+	default:
+		return nil, errors.New("operation must be status, priority, due_date, move or delete")
+	}
+	return op, nil
+}
+
+// apply runs the operation on one item. Items are re-read inside tx because an
+// earlier item in the same run may have moved or deleted them with their parent.
+// A bulkItemError fails only the item.
+func (op *bulkOperation) apply(tx *gorm.DB, itemID uint) (warning string, err error) {
+	var item models.ProjectItem
+	if err := tx.First(&item, itemID).Error; err != nil {
+		if op.operation == "delete" {
+			return "Already deleted along with its parent", nil
+		}
+		return "", bulkItemError("Item not found")
+	}
+
+	switch op.operation {
+	case "status":
+		if item.Status == op.status {
+			return "", nil
+		}
+		if err := checkStatusChange(tx, item.BoardID, item.Status, op.status); err != nil {
+			if errors.Is(err, errUnknownStatus) || errors.Is(err, errTransitionNotAllowed) {
+				return "", bulkItemError(err.Error())
+			}
+			return "", err
+		}
+		wasComplete, err := isCompleteStatus(tx, item.BoardID, item.Status)
+		if err != nil {
+			return "", err
+		}
+		isComplete, err := isCompleteStatus(tx, item.BoardID, op.status)
+		if err != nil {
+			return "", err
+		}
+		wip, _, err := evaluateWIP(tx, item.BoardID, op.status, op.userID, "")
+		if errors.Is(err, errWIPLimitReached) {
+			return "", bulkItemError(wip.message())
+		}
+		if err != nil {
+			return "", err
+		}
+		if wip != nil {
+			warning = wip.message()
+		}
+		enforce, err := enforcesBlockers(tx, item.BoardID)
+		if err != nil {
+			return "", err
+		}
+		if enforce && !wasComplete && isComplete {
+			blockers, err := openBlockers(tx, item.ID)
+			if err != nil {
+				return "", err
+			}
+			if len(blockers) > 0 {
+				return "", bulkItemError(fmt.Sprintf("Item is blocked by unfinished items %v", blockers))
+			}
+		}
+		item.Status = op.status
+		if err := tx.Model(&item).Update("status", item.Status).Error; err != nil {
+			return "", err
+		}
+		if !wasComplete && isComplete {
+			if _, err := completeOccurrence(tx, &item); err != nil {
+				return "", err
+			}
+		}
+		return warning, nil
+
+	case "priority":
+		return "", tx.Model(&item).Update("priority", op.priority).Error
+
+	case "due_date":
+		return "", tx.Model(&item).Update("due_date", op.dueDate).Error
+
+	case "move":
+		if item.BoardID == op.target.ID {
+			return "Already on the target board", nil
+		}
+		transfer, ok := op.transfers[item.BoardID]
+		if !ok {
+			var src models.Board
+			if err := tx.First(&src, item.BoardID).Error; err != nil {
+				return "", err
+			}
+			if transfer, err = newItemTransfer(tx, &src, op.target); err != nil {
+				return "", err
+			}
+			op.transfers[item.BoardID] = transfer
+		}
+		items := []models.ProjectItem{item}
+		ids, err := descendantIDs(tx, item.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(ids) > 0 {
+			var children []models.ProjectItem
+			if err := tx.Where("id IN ?", ids).Find(&children).Error; err != nil {
+				return "", err
+			}
+			items = append(items, sortParentsFirst(children)...)
+		}
+		wip, err := transfer.checkWIP(tx, items, "", op.userID, "")
+		if errors.Is(err, errWIPLimitReached) {
+			return "", bulkItemError(wip[len(wip)-1].message())
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(wipWarnings(wip), "; "), transfer.move(tx, items, "")
+
+	case "delete":
+		// Subtasks go to the trash with the item and restore with it
+		ids, err := descendantIDs(tx, item.ID)
+		if err != nil {
+			return "", err
+		}
+		return "", trashItems(tx, append(ids, item.ID))
+	}
+	return "", nil
+}
+
+// bulkTargets resolves the items a bulk request is about, in a stable order.
+func bulkTargets(req *bulkItemsRequest, userID uint) ([]models.ProjectItem, error) {
+	var items []models.ProjectItem
+	if len(req.IDs) > 0 {
+		err := config.DB.Where("id IN ?", req.IDs).Order("id").Find(&items).Error
+		return items, err
+	}
+
+	boardIDs, err := accessibleBoardIDs(userID)
+	if err != nil || len(boardIDs) == 0 {
+		return items, err
+	}
+	query := config.DB.Where("board_id IN ?", boardIDs)
+	if req.Filter.BoardID != 0 {
+		query = query.Where("board_id = ?", req.Filter.BoardID)
+	}
+	if len(req.Filter.Status) > 0 {
+		query = query.Where("status IN ?", req.Filter.Status)
+	}
+	if len(req.Filter.Priority) > 0 {
+		query = query.Where("priority IN ?", req.Filter.Priority)
+	}
+	err = query.Order("id").Limit(bulkMaxItems + 1).Find(&items).Error
+	return items, err
+}
+
+// ✅ Apply one operation to many items in a single transaction
+func BulkUpdateProjectItems(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body bulkItemsRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	if (len(body.IDs) > 0) == (body.Filter != nil) {
+		return apperr.Invalid("Give either ids or filter")
+	}
+	if body.Mode == "" {
+		body.Mode = bulkAllOrNothing
+	}
+	if body.Mode != bulkAllOrNothing && body.Mode != bulkBestEffort {
+		return apperr.Invalid("mode must be all_or_nothing or best_effort", apperr.Field("mode", "must be all_or_nothing or best_effort"))
+	}
+	if len(body.IDs) > bulkMaxItems {
+		return apperr.Invalid(fmt.Sprintf("A bulk request can touch at most %d items", bulkMaxItems))
+	}
+
+	op, err := parseBulkOperation(&body, currentUserID)
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	items, err := bulkTargets(&body, currentUserID)
+	if err != nil {
+		return apperr.Internal("Could not load items", err)
+	}
+	if len(items) > bulkMaxItems {
+		return apperr.Invalid(fmt.Sprintf("The filter matches more than %d items, narrow it down", bulkMaxItems))
+	}
+
+	// Every requested ID gets a result, including ones that don't exist
+	found := make(map[uint]*models.ProjectItem, len(items))
+	for i := range items {
+		found[items[i].ID] = &items[i]
+	}
+	order := make([]uint, 0, len(items))
+	if len(body.IDs) > 0 {
+		seen := map[uint]bool{}
+		for _, id := range body.IDs {
+			if !seen[id] {
+				seen[id] = true
+				order = append(order, id)
+			}
+		}
+	} else {
+		for _, item := range items {
+			order = append(order, item.ID)
+		}
+	}
+
+	access := map[uint]bool{}
+	results := make([]bulkResult, len(order))
+	failed := false
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range order {
+			results[i] = bulkResult{ID: id, Result: "ok"}
+			item, ok := found[id]
+			if !ok {
+				results[i].Result, results[i].Error = "failed", "Item not found"
+			} else {
+				allowed, checked := access[item.BoardID]
+				if !checked {
+					allowed = canAccessBoard(currentUserID, item.BoardID)
+					access[item.BoardID] = allowed
+				}
+				if !allowed {
+					results[i].Result, results[i].Error = "failed", "You do not have access to this board"
+				}
+			}
+
+			if results[i].Result == "ok" {
+				var warning string
+				var err error
+				if body.Mode == bulkBestEffort {
+					// A savepoint per item, so one failure only undoes that item
+					err = tx.Transaction(func(sp *gorm.DB) error {
+						warning, err = op.apply(sp, id)
+						return err
+					})
+				} else {
+					warning, err = op.apply(tx, id)
+				}
+				results[i].Warning = warning
+				var failure bulkItemError
+				if errors.As(err, &failure) {
+					results[i].Result, results[i].Error = "failed", failure.Error()
+				} else if err != nil {
+					return err
+				}
+			}
+
+			if results[i].Result == "failed" {
+				failed = true
+				if body.Mode == bulkAllOrNothing {
+					return errBulkAborted
+				}
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkAborted) {
+		for i := range results {
+			if results[i].Result == "ok" {
+				results[i].Result, results[i].Warning = "rolled_back", ""
+			} else if results[i].Result == "" {
+				results[i] = bulkResult{ID: order[i], Result: "rolled_back"}
+			}
+		}
+		return apperr.Conflict("An item failed, nothing was changed").WithCode(codeBulkItemFailed).With("results", results)
+	}
+	if err != nil {
+		return apperr.Internal("Could not apply bulk operation", err)
+	}
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Result == "ok" {
+			succeeded++
+		}
+	}
+	response := fiber.Map{
+		"operation": body.Operation,
+		"mode":      body.Mode,
+		"matched":   len(order),
+		"succeeded": succeeded,
+		"failed":    len(order) - succeeded,
+		"partial":   failed,
+		"results":   results,
+	}
+	if len(op.transfers) > 0 {
+		reports := make(map[uint]transferReport, len(op.transfers))
+		for boardID, transfer := range op.transfers {
+			reports[boardID] = transfer.report
+		}
+		response["move_reports"] = reports // by source board
+	}
+	return c.JSON(response)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+)
+
+func TestParseBulkOperation(t *testing.T) {
+	tests := []struct {
+		operation, value string
+		err              string // empty when the request is valid
+	}{
+		{"status", `"in_progress"`, ""},
+		{"status", `""`, "value must be a status key"},
+		{"status", `7`, "value must be a status key"},
+		{"priority", `"high"`, ""},
+		{"priority", `"urgent"`, "value must be low, medium or high"},
+		{"priority", `3`, "value must be low, medium or high"},
+		{"priority", ``, "value must be low, medium or high"},
+		{"due_date", `"2024-06-01T09:00:00Z"`, ""},
+		{"due_date", `null`, ""},
+		{"due_date", `"tomorrow"`, "value must be an RFC3339 date or null"},
+		{"move", `0`, "value must be the target board ID"},
+		{"move", `"5"`, "value must be the target board ID"},
+		{"delete", ``, ""},
+		{"assignee", `4`, "Items have no assignees yet"},
+		{"label", `"bug"`, "Items have no labels yet"},
+		{"archive", ``, "operation must be status, priority, due_date, move or delete"},
+	}
+	for _, tt := range tests {
+		req := &bulkItemsRequest{Operation: tt.operation, Value: json.RawMessage(tt.value)}
+		op, err := parseBulkOperation(req, 1)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s %s: %v", tt.operation, tt.value, err)
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("%s %s: err = %v, want %q", tt.operation, tt.value, err, tt.err)
+		case tt.err == "" && op.operation != tt.operation:
+			t.Errorf("%s %s: operation = %q", tt.operation, tt.value, op.operation)
+		}
+	}
+
+	op, _ := parseBulkOperation(&bulkItemsRequest{Operation: "due_date", Value: json.RawMessage(`"2024-06-01T09:00:00Z"`)}, 1)
+	if want := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC); op.dueDate == nil || !op.dueDate.Equal(want) {
+		t.Errorf("due date = %v, want %v", op.dueDate, want)
+	}
+}
+
+// Moving an item takes its subtasks along, so each target status must have
+// room for every item that lands in it, not only the one asked for.
+func TestBulkMoveChecksWIPForSubtasks(t *testing.T) {
+	db, fake := openFakeDB(t)
+	limit := 3
+	src := &models.Board{}
+	src.ID = 1
+	dst := &models.Board{}
+	dst.ID = 2
+	transfer := &itemTransfer{
+		src: src, dst: dst,
+		srcStatuses: []models.BoardStatus{{Key: "doing", Category: models.CategoryActive}},
+		dstStatuses: []models.BoardStatus{{Key: "doing", Category: models.CategoryActive}},
+		report:      transferReport{StatusMap: map[string]string{}},
+	}
+	op := &bulkOperation{userID: 9, operation: "move", target: dst, transfers: map[uint]*itemTransfer{1: transfer}}
+
+	item := []string{"id", "board_id", "status", "parent_id"}
+	fake.answer(item, []driver.Value{int64(10), int64(1), "doing", nil})              // the item
+	fake.answer([]string{"id"}, []driver.Value{int64(11)}, []driver.Value{int64(12)}) // its subtasks
+	fake.answer([]string{"id"})                                                       // and theirs: none
+	fake.answer(item,
+		[]driver.Value{int64(11), int64(1), "doing", int64(10)},
+		[]driver.Value{int64(12), int64(1), "doing", int64(10)})
+	fake.answer([]string{"id", "board_id", "status_key", "wip_limit", "wip_policy"},
+		[]driver.Value{int64(5), int64(2), "doing", int64(limit), string(models.WIPHard)})
+	fake.answer([]string{"count"}, []driver.Value{int64(1)}) // one item is already there
+
+	var failure bulkItemError
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := op.apply(tx, 10)
+		return err
+	})
+	if !errors.As(err, &failure) || !strings.Contains(failure.Error(), `WIP limit for "doing" is 3`) {
+		t.Errorf("err = %v, want the WIP limit to stop the move", err)
+	}
+	for _, statement := range fake.sent() {
+		if strings.HasPrefix(statement, "UPDATE") {
+			t.Errorf("moved despite the limit: %s", statement)
+		}
+	}
+}
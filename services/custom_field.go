@@ -0,0 +1,520 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// defaultTextFieldLength matches the size of CustomFieldValue.TextValue.
+const defaultTextFieldLength = 1024
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// errSelectionTooLong is a multi-select value whose options, together, don't
+// fit in the column that stores them. The value is valid; the field has more
+// or longer options than can be chosen at once.
+var errSelectionTooLong = errors.New("too many options chosen to store")
+
+// customFieldError is a bad custom field value in an item payload, as opposed
+// to a database failure while saving it.
+func customFieldError(key, msg string) *apperr.Error {
+	return apperr.Invalid(msg, apperr.Field("custom_fields."+key, msg))
+}
+
+type customFieldRequest struct {
+	Key       string   `json:"key" validate:"required"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Type      string   `json:"type" validate:"required,oneof=text number date single_select multi_select"`
+	Options   []string `json:"options"`
+	Required  bool     `json:"required"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	MaxLength int      `json:"max_length"`
+	Position  int      `json:"position"`
+}
+
+// validateFieldDefinition checks that a field definition is usable.
+func validateFieldDefinition(field *models.CustomField) error {
+	if !fieldKeyPattern.MatchString(field.Key) {
+		return errors.New("key must be lowercase letters, digits or underscores and start with a letter")
+	}
+	if strings.TrimSpace(field.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	switch field.Type {
+	case models.FieldText:
+		if field.MaxLength < 0 || field.MaxLength > defaultTextFieldLength {
+			return fmt.Errorf("max_length must be between 1 and %d, or 0 for the default of %[1]d", defaultTextFieldLength)
+		}
+	case models.FieldNumber:
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return errors.New("min cannot be greater than max")
+		}
+	case models.FieldDate:
+	case models.FieldSingleSelect, models.FieldMultiSelect:
+		if len(field.Options) == 0 {
+			return errors.New("select fields need at least one option")
+		}
+		seen := make(map[string]bool, len(field.Options))
+		for _, option := range field.Options {
+			if option == "" || seen[option] {
+				return errors.New("options must be unique and non-empty")
+			}
+			seen[option] = true
+		}
+	default:
+		return errors.New("type must be text, number, date, single_select or multi_select")
+	}
+	return nil
+}
+
+// parseFieldDate accepts RFC3339 timestamps or plain YYYY-MM-DD dates.
+func parseFieldDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// encodeFieldValue validates a raw JSON value against a field and stores it in
+// the column that matches the field type.
+func encodeFieldValue(field *models.CustomField, raw interface{}) (models.CustomFieldValue, error) {
+	value := models.CustomFieldValue{FieldID: field.ID}
+
+	switch field.Type {
+	case models.FieldText:
+		s, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("%s must be text", field.Key)
+		}
+		limit := field.MaxLength
+		if limit == 0 {
+			limit = defaultTextFieldLength
+		}
+		if utf8.RuneCountInString(s) > limit {
+			return value, fmt.Errorf("%s must be at most %d characters", field.Key, limit)
+		}
+		value.TextValue = s
+
+	case models.FieldNumber:
+		n, ok := raw.(float64)
+		if !ok {
+			return value, fmt.Errorf("%s must be a number", field.Key)
+		}
+		if field.Min != nil && n < *field.Min {
+			return value, fmt.Errorf("%s must be at least %v", field.Key, *field.Min)
+		}
+		if field.Max != nil && n > *field.Max {
+			return value, fmt.Errorf("%s must be at most %v", field.Key, *field.Max)
+		}
+		value.NumberValue = &n
+
+	case models.FieldDate:
+		s, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("%s must be a date", field.Key)
+		}
+		t, err := parseFieldDate(s)
+		if err != nil {
+			return value, fmt.Errorf("%s must be a date (YYYY-MM-DD or RFC3339)", field.Key)
+		}
+		value.DateValue = &t
+
+	case models.FieldSingleSelect:
+		s, ok := raw.(string)
+		if !ok || !containsString(field.Options, s) {
+			return value, fmt.Errorf("%s must be one of %s", field.Key, strings.Join(field.Options, ", "))
+		}
+		value.TextValue = s
+
+	case models.FieldMultiSelect:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return value, fmt.Errorf("%s must be a list", field.Key)
+		}
+		chosen := make([]string, 0, len(list))
+		seen := make(map[string]bool, len(list))
+		for _, v := range list {
+			s, ok := v.(string)
+			if !ok || !containsString(field.Options, s) {
+				return value, fmt.Errorf("%s values must be from %s", field.Key, strings.Join(field.Options, ", "))
+			}
+			if !seen[s] {
+				seen[s] = true
+				chosen = append(chosen, s)
+			}
+		}
+		sort.Strings(chosen)
+		encoded, _ := json.Marshal(chosen)
+		if utf8.RuneCount(encoded) > defaultTextFieldLength {
+			return value, fmt.Errorf("%s: %w", field.Key, errSelectionTooLong)
+		}
+		value.TextValue = string(encoded)
+	}
+	return value, nil
+}
+
+// decodeFieldValue turns a stored value back into its JSON representation.
+func decodeFieldValue(field *models.CustomField, value *models.CustomFieldValue) interface{} {
+	switch field.Type {
+	case models.FieldNumber:
+		if value.NumberValue != nil {
+			return *value.NumberValue
+		}
+	case models.FieldDate:
+		if value.DateValue != nil {
+			return *value.DateValue
+		}
+	case models.FieldMultiSelect:
+		var chosen []string
+		json.Unmarshal([]byte(value.TextValue), &chosen)
+		return chosen
+	default:
+		return value.TextValue
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// saveCustomFieldValues validates values (keyed by field key) against the board's
+// fields and writes them for the item. A null value clears the field.
+// When creating, required fields must be present.
+func saveCustomFieldValues(tx *gorm.DB, boardID, itemID uint, values map[string]interface{}, creating bool) error {
+	var fields []models.CustomField
+	if err := tx.Where("board_id = ?", boardID).Find(&fields).Error; err != nil {
+		return err
+	}
+	byKey := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	for key := range values {
+		if _, ok := byKey[key]; !ok {
+			return customFieldError(key, fmt.Sprintf("unknown custom field %q", key))
+		}
+	}
+
+	for _, field := range byKey {
+		raw, present := values[field.Key]
+		if field.Required && ((creating && !present) || (present && raw == nil)) {
+			return customFieldError(field.Key, field.Key+" is required")
+		}
+		if !present {
+			continue
+		}
+
+		if raw == nil {
+			if err := tx.Where("item_id = ? AND field_id = ?", itemID, field.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := encodeFieldValue(field, raw)
+		if errors.Is(err, errSelectionTooLong) {
+			tooLong := apperr.Unprocessable(err.Error())
+			tooLong.Fields = []apperr.FieldError{apperr.Field("custom_fields."+field.Key, err.Error())}
+			return tooLong
+		}
+		if err != nil {
+			return customFieldError(field.Key, err.Error())
+		}
+		value.ItemID = itemID
+
+		var existing models.CustomFieldValue
+		if err := tx.Where("item_id = ? AND field_id = ?", itemID, field.ID).First(&existing).Error; err == nil {
+			value.ID = existing.ID
+		}
+		if err := tx.Save(&value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachCustomFields fills in the custom field values of every item.
+func attachCustomFields(items []models.ProjectItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	index := make(map[uint]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		index[items[i].ID] = i
+	}
+
+	var values []models.CustomFieldValue
+	if err := config.DB.Preload("Field").Where("item_id IN ?", ids).Find(&values).Error; err != nil {
+		return err
+	}
+
+	for i := range values {
+		field := values[i].Field
+		if field == nil {
+			continue // field was deleted
+		}
+		item := &items[index[values[i].ItemID]]
+		if item.CustomFields == nil {
+			item.CustomFields = map[string]interface{}{}
+		}
+		item.CustomFields[field.Key] = decodeFieldValue(field, &values[i])
+	}
+	return nil
+}
+
+// applyCustomFieldQuery narrows a board's item query by custom fields:
+//
+//	cf.<key>=value        text contains, select equals, multi-select includes, number/date equals
+//	cf.<key>.min=value    number/date lower bound (inclusive)
+//	cf.<key>.max=value    number/date upper bound (inclusive)
+//
+// Sorting by a custom field is done by itemSortOrder.
+func applyCustomFieldQuery(c *fiber.Ctx, query *gorm.DB, boardID uint) (*gorm.DB, error) {
+	var fields []models.CustomField
+	if err := config.DB.Where("board_id = ?", boardID).Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	// Each field gets its own join alias, created once.
+	aliases := map[uint]string{}
+	join := func(field *models.CustomField) string {
+		if alias, ok := aliases[field.ID]; ok {
+			return alias
+		}
+		alias := "cf" + strconv.Itoa(len(aliases))
+		aliases[field.ID] = alias
+		query = query.Joins("JOIN custom_field_values AS "+alias+" ON "+alias+".item_id = project_items.id AND "+alias+".field_id = ?", field.ID)
+		return alias
+	}
+
+	params := c.Queries()
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if strings.HasPrefix(k, "cf.") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys) // stable alias numbering
+
+	for _, param := range keys {
+		raw := params[param]
+		key, bound, _ := strings.Cut(strings.TrimPrefix(param, "cf."), ".")
+		field, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("Unknown custom field %q", key)
+		}
+		alias := join(field)
+
+		switch field.Type {
+		case models.FieldNumber:
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid number for %s", param)
+			}
+			op, err := boundOperator(bound)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(alias+".number_value "+op+" ?", n)
+		case models.FieldDate:
+			t, err := parseFieldDate(raw)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid date for %s", param)
+			}
+			op, err := boundOperator(bound)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(alias+".date_value "+op+" ?", t)
+		case models.FieldText:
+			query = query.Where("LOWER("+alias+".text_value) LIKE ?", "%"+escapeLike(strings.ToLower(raw))+"%")
+		case models.FieldSingleSelect:
+			query = query.Where(alias+".text_value = ?", raw)
+		case models.FieldMultiSelect:
+			encoded, _ := json.Marshal(raw)
+			query = query.Where(alias+".text_value LIKE ?", "%"+escapeLike(string(encoded))+"%")
+		}
+	}
+
+	return query, nil
+}
+
+func boundOperator(bound string) (string, error) {
+	switch bound {
+	case "":
+		return "=", nil
+	case "min":
+		return ">=", nil
+	case "max":
+		return "<=", nil
+	}
+	return "", fmt.Errorf("Unknown filter suffix %q, use min or max", bound)
+}
+
+// escapeLike escapes the LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// loadAdminBoard fetches the board in the :id param and checks the caller can
+// change its configuration. On failure it returns nil and the error to respond with.
+func loadAdminBoard(c *fiber.Ctx) (*models.Board, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return nil, apperr.NotFound("Board not found")
+	}
+	if !canAdminBoard(currentUserID, board.ID) {
+		return nil, apperr.Forbidden("Only board admins can change board settings")
+	}
+	return &board, nil
+}
+
+// ✅ List a board's custom fields
+func GetBoardCustomFields(c *fiber.Ctx) error {
+	var fields []models.CustomField
+	if err := config.DB.Where("board_id = ?", c.Params("id")).Order("position, id").Find(&fields).Error; err != nil {
+		return apperr.Internal("Could not fetch custom fields", err)
+	}
+	return c.JSON(fields)
+}
+
+// ✅ Define a custom field on a board (board admins only)
+func CreateBoardCustomField(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var body customFieldRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+
+	field := models.CustomField{
+		BoardID:   board.ID,
+		Key:       body.Key,
+		Name:      body.Name,
+		Type:      models.CustomFieldType(body.Type),
+		Options:   body.Options,
+		Required:  body.Required,
+		Min:       body.Min,
+		Max:       body.Max,
+		MaxLength: body.MaxLength,
+		Position:  body.Position,
+	}
+	if err := validateFieldDefinition(&field); err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	var count int64
+	config.DB.Model(&models.CustomField{}).Where("board_id = ? AND field_key = ?", board.ID, field.Key).Count(&count)
+	if count > 0 {
+		return apperr.Conflict("A field with this key already exists on the board")
+	}
+
+	if err := config.DB.Create(&field).Error; err != nil {
+		return apperr.Internal("Could not create custom field", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(field)
+}
+
+// ✅ Update a custom field (board admins only). The key and type are fixed
+// once created so that stored values stay valid.
+func UpdateBoardCustomField(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var field models.CustomField
+	if err := config.DB.Where("board_id = ?", board.ID).First(&field, c.Params("fieldId")).Error; err != nil {
+		return apperr.NotFound("Custom field not found")
+	}
+
+	var body customFieldRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	if (body.Key != "" && body.Key != field.Key) || (body.Type != "" && models.CustomFieldType(body.Type) != field.Type) {
+		return apperr.Invalid("key and type cannot be changed, create a new field instead")
+	}
+
+	field.Name = body.Name
+	field.Options = body.Options
+	field.Required = body.Required
+	field.Min = body.Min
+	field.Max = body.Max
+	field.MaxLength = body.MaxLength
+	field.Position = body.Position
+	if err := validateFieldDefinition(&field); err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	if err := config.DB.Save(&field).Error; err != nil {
+		return apperr.Internal("Could not update custom field", err)
+	}
+
+	return c.JSON(field)
+}
+
+// ✅ Delete a custom field and its values (board admins only)
+func DeleteBoardCustomField(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var field models.CustomField
+	if err := config.DB.Where("board_id = ?", board.ID).First(&field, c.Params("fieldId")).Error; err != nil {
+		return apperr.NotFound("Custom field not found")
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", field.ID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		// Hard delete so the key can be reused
+		return tx.Unscoped().Delete(&field).Error
+	})
+	if err != nil {
+		return apperr.Internal("Could not delete custom field", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestEncodeFieldValueLength(t *testing.T) {
+	text := &models.CustomField{Key: "notes", Type: models.FieldText, MaxLength: 5}
+	// Counted in characters, like the column
+	if _, err := encodeFieldValue(text, "ééééé"); err != nil {
+		t.Errorf("five characters in ten bytes: %v", err)
+	}
+	if _, err := encodeFieldValue(text, "éééééé"); err == nil {
+		t.Error("six characters were accepted")
+	}
+
+	options := make([]string, 20)
+	chosen := make([]interface{}, len(options))
+	for i := range options {
+		options[i] = strings.Repeat(string(rune('a'+i)), 60)
+		chosen[i] = options[i]
+	}
+	tags := &models.CustomField{Key: "tags", Type: models.FieldMultiSelect, Options: options}
+	if _, err := encodeFieldValue(tags, chosen[:5]); err != nil {
+		t.Errorf("five tags: %v", err)
+	}
+	if _, err := encodeFieldValue(tags, chosen); !errors.Is(err, errSelectionTooLong) {
+		t.Errorf("all twenty tags: err = %v, want errSelectionTooLong", err)
+	}
+}
+
+func TestValidateFieldDefinitionMaxLength(t *testing.T) {
+	for length, ok := range map[int]bool{-1: false, 0: true, 1: true, defaultTextFieldLength: true, defaultTextFieldLength + 1: false} {
+		field := &models.CustomField{Key: "notes", Name: "Notes", Type: models.FieldText, MaxLength: length}
+		if err := validateFieldDefinition(field); (err == nil) != ok {
+			t.Errorf("max_length %d: err = %v", length, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package services
+
+// Error codes more specific than apperr's generic ones, for conflicts a client
+// can resolve on its own.
+const (
+	codeWIPLimitReached      = "wip_limit_reached"
+	codeTransitionNotAllowed = "transition_not_allowed"
+	codeItemBlocked          = "item_blocked"
+	codeItemHasChildren      = "item_has_children"
+	codeStatusInUse          = "status_in_use"
+	codeBoardInTrash         = "board_in_trash"
+	codeBulkItemFailed       = "bulk_item_failed"
+	codeEmailTaken           = "email_taken"
+	codeVersionConflict      = "version_conflict"
+	codeIdempotencyInFlight  = "idempotency_key_in_use"
+	codeIdempotencyMismatch  = "idempotency_key_reused"
+	codeMemberExists         = "member_exists"
+	codeNotCurrentOccurrence = "not_current_occurrence"
+)
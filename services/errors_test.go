@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Only the workflow, hierarchy and WIP sentinels reach the client; anything else,
+// such as a database error, is a 500 whose message stays in the log.
+func TestValidationErrorsHideInternalOnes(t *testing.T) {
+	dbErr := errors.New("Error 1205 (HY000): Lock wait timeout exceeded")
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{"unknown status", statusChangeError(errUnknownStatus, "Could not update item"), fiber.StatusBadRequest, apperr.CodeValidation, "status"},
+		{"transition", statusChangeError(errTransitionNotAllowed, "Could not update item"), fiber.StatusConflict, codeTransitionNotAllowed, ""},
+		{"wrapped transition", statusChangeError(fmt.Errorf("moving: %w", errTransitionNotAllowed), "Could not update item"), fiber.StatusConflict, codeTransitionNotAllowed, ""},
+		{"status lookup failed", statusChangeError(dbErr, "Could not update item"), fiber.StatusInternalServerError, apperr.CodeInternal, ""},
+		{"parent not found", parentError(errParentNotFound, "Could not create item"), fiber.StatusBadRequest, apperr.CodeValidation, "parent_id"},
+		{"parent cycle", parentError(errParentCycle, "Could not create item"), fiber.StatusBadRequest, apperr.CodeValidation, "parent_id"},
+		{"parent too deep", parentError(errParentTooDeep, "Could not create item"), fiber.StatusBadRequest, apperr.CodeValidation, "parent_id"},
+		{"parent lookup failed", parentError(dbErr, "Could not create item"), fiber.StatusInternalServerError, apperr.CodeInternal, ""},
+		{"WIP limit", wipError(&wipCheck{Status: "doing", Limit: 3, Load: 3}, errWIPLimitReached, "Could not update item"), fiber.StatusConflict, codeWIPLimitReached, ""},
+		{"WIP lookup failed", wipError(nil, dbErr, "Could not update item"), fiber.StatusInternalServerError, apperr.CodeInternal, ""},
+	}
+	for _, tt := range tests {
+		var got *apperr.Error
+		if !errors.As(tt.err, &got) {
+			t.Errorf("%s: %v is not an apperr.Error", tt.name, tt.err)
+			continue
+		}
+		if got.Status != tt.status || got.Code != tt.code {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, got.Status, got.Code, tt.status, tt.code)
+		}
+		if tt.field != "" && (len(got.Fields) != 1 || got.Fields[0].Field != tt.field) {
+			t.Errorf("%s: fields = %v, want one on %s", tt.name, got.Fields, tt.field)
+		}
+		if got.Status == fiber.StatusInternalServerError && got.Detail == dbErr.Error() {
+			t.Errorf("%s: the database error is sent to the client", tt.name)
+		}
+	}
+}
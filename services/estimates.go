@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var errInvalidEstimate = errors.New("estimate must be zero or more")
+
+// parseEstimateUnit maps the request value to a unit, defaulting to story points.
+func parseEstimateUnit(value string) (models.EstimateUnit, bool) {
+	switch models.EstimateUnit(value) {
+	case "", models.EstimatePoints:
+		return models.EstimatePoints, true
+	case models.EstimateHours:
+		return models.EstimateHours, true
+	}
+	return "", false
+}
+
+// validateEstimate checks an estimate against the board's unit.
+// Story points are whole numbers, hours may be fractional.
+func validateEstimate(board *models.Board, estimate *float64) error {
+	if estimate == nil {
+		return nil
+	}
+	if *estimate < 0 {
+		return errInvalidEstimate
+	}
+	if board.EstimateUnit == models.EstimatePoints && *estimate != float64(int64(*estimate)) {
+		return errors.New("this board estimates in story points, use a whole number")
+	}
+	return nil
+}
+
+// attachBoardEstimates rolls up item estimates for every board.
+func attachBoardEstimates(boards []models.Board) error {
+	if len(boards) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(boards))
+	for i := range boards {
+		ids[i] = boards[i].ID
+	}
+
+	var rows []struct {
+		BoardID   uint
+		Items     int
+		Estimated int
+		Total     float64
+		Done      float64
+	}
+	err := config.DB.Model(&models.ProjectItem{}).
+		Select("board_id, COUNT(*) AS items, COUNT(estimate) AS estimated, "+
+			"COALESCE(SUM(estimate), 0) AS total, "+
+			"COALESCE(SUM(CASE WHEN "+completeStatusSQL+" THEN estimate ELSE 0 END), 0) AS done").
+		Where("board_id IN ?", ids).
+		Group("board_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byBoard := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		byBoard[row.BoardID] = i
+	}
+	for i := range boards {
+		summary := &models.EstimateSummary{Unit: boards[i].EstimateUnit}
+		if j, ok := byBoard[boards[i].ID]; ok {
+			row := rows[j]
+			summary.Items = row.Items
+			summary.Estimated = row.Estimated
+			summary.Total = row.Total
+			summary.Done = row.Done
+			summary.Remaining = row.Total - row.Done
+		}
+		boards[i].Estimates = summary
+	}
+	return nil
+}
+
+// applyEstimateQuery narrows an item query from the request:
+// estimate_min, estimate_max and has_estimate=true|false. Sorting by estimate
+// is done by itemSortOrder.
+func applyEstimateQuery(c *fiber.Ctx, query *gorm.DB) (*gorm.DB, error) {
+	if v := c.Query("estimate_min"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.New("Invalid estimate_min")
+		}
+		query = query.Where("project_items.estimate >= ?", n)
+	}
+	if v := c.Query("estimate_max"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.New("Invalid estimate_max")
+		}
+		query = query.Where("project_items.estimate <= ?", n)
+	}
+	switch c.Query("has_estimate") {
+	case "":
+	case "true":
+		query = query.Where("project_items.estimate IS NOT NULL")
+	case "false":
+		query = query.Where("project_items.estimate IS NULL")
+	default:
+		return nil, errors.New("Invalid has_estimate, use true or false")
+	}
+
+	return query, nil
+}
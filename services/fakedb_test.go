@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB stands in for MySQL in tests: it accepts every statement, records
+// it, and answers queries with the rows queued for them. That is enough to
+// check the SQL a piece of code sends and to drive GORM's transactions.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []string // each with its arguments filled in
+	results    []fakeRows
+	affected   []int64 // rows the next statements change, 1 once it runs out
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error // returned by the query instead of rows
+}
+
+// openFakeDB opens GORM on a new fakeDB.
+func openFakeDB(t *testing.T) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	fake := &fakeDB{}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(fake), SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent), // tests provoke errors on purpose
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+// answer queues the rows the next query gets; queries beyond the queue get none.
+func (f *fakeDB) answer(columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, fakeRows{columns: columns, rows: rows})
+}
+
+// fail makes the next query return err.
+func (f *fakeDB) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, fakeRows{err: err})
+}
+
+// affects makes the next statement change n rows.
+func (f *fakeDB) affects(n int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.affected = append(f.affected, n)
+}
+
+// sent returns the statements run so far and forgets them.
+func (f *fakeDB) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := f.statements
+	f.statements = nil
+	return out
+}
+
+func (f *fakeDB) record(query string, args []driver.NamedValue) {
+	for _, arg := range args {
+		query = strings.Replace(query, "?", fmt.Sprintf("%#v", arg.Value), 1)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, query)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)         { return c, nil }
+func (fakeConn) Commit() error                       { return nil }
+func (fakeConn) Rollback() error                     { return nil }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if len(c.db.affected) == 0 {
+		return fakeResult(1), nil
+	}
+	n := c.db.affected[0]
+	c.db.affected = c.db.affected[1:]
+	return fakeResult(n), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query, args)
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if len(c.db.results) == 0 {
+		return &fakeRows{}, nil
+	}
+	next := c.db.results[0]
+	c.db.results = c.db.results[1:]
+	if next.err != nil {
+		return nil, next.err
+	}
+	return &next, nil
+}
+
+// fakeResult is the rows a statement changed; inserts get ID 1.
+type fakeResult int64
+
+func (fakeResult) LastInsertId() (int64, error)   { return 1, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// How many recently viewed boards, and separately items, are kept per user
+var recentViewsLimit = parseEnvInt("RECENT_VIEWS_LIMIT", 20)
+
+// How far ahead the home page looks for due items
+const homeDueWindow = 7 * 24 * time.Hour
+
+type reorderStarsRequest struct {
+	BoardIDs []uint `json:"board_ids"` // every starred board, in the new order
+}
+
+// Views of the same board or item closer together than this are recorded once
+const recentViewInterval = time.Minute
+
+// recordView remembers that the user opened a board or an item (exactly one
+// of boardID and itemID is set). It runs in the background so a read doesn't
+// wait on a write, and is best effort: failures are logged and never fail the
+// request.
+func recordView(userID uint, boardID, itemID *uint) {
+	if userID == 0 {
+		return
+	}
+	go saveView(config.DB, userID, boardID, itemID, time.Now())
+}
+
+// saveView records a view at now and forgets views past recentViewsLimit.
+// Opening the same thing again within recentViewInterval writes nothing.
+func saveView(db *gorm.DB, userID uint, boardID, itemID *uint, now time.Time) {
+	column, id := "board_id", boardID
+	if itemID != nil {
+		column, id = "item_id", itemID
+	}
+
+	var view models.RecentView
+	if err := db.Where("user_id = ? AND "+column+" = ?", userID, *id).First(&view).Error; err != nil {
+		view = models.RecentView{UserID: userID, BoardID: boardID, ItemID: itemID}
+	} else if now.Sub(view.ViewedAt) < recentViewInterval {
+		return
+	}
+	view.ViewedAt = now
+	if err := db.Save(&view).Error; err != nil {
+		log.Printf("Could not record view for user %d: %v", userID, err)
+		return
+	}
+
+	var ids []uint
+	err := db.Model(&models.RecentView{}).
+		Where("user_id = ? AND "+column+" IS NOT NULL", userID).
+		Order("viewed_at DESC").
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) <= recentViewsLimit {
+		return
+	}
+	db.Delete(&models.RecentView{}, ids[recentViewsLimit:])
+}
+
+// boardsInOrder loads boards and returns them in the order of ids, skipping
+// any that no longer exist or that userID can no longer access.
+func boardsInOrder(userID uint, ids []uint) ([]models.Board, error) {
+	boards := []models.Board{}
+	if len(ids) == 0 {
+		return boards, nil
+	}
+	var found []models.Board
+	if err := config.DB.Scopes(accessibleBoards(userID)).Where("boards.id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Board, len(found))
+	for _, b := range found {
+		byID[b.ID] = b
+	}
+	for _, id := range ids {
+		if b, ok := byID[id]; ok {
+			boards = append(boards, b)
+		}
+	}
+	return boards, nil
+}
+
+// itemsInOrder is boardsInOrder for items.
+func itemsInOrder(userID uint, ids []uint) ([]models.ProjectItem, error) {
+	items := []models.ProjectItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+	var found []models.ProjectItem
+	boards := config.DB.Model(&models.Board{}).Select("id").Scopes(accessibleBoards(userID))
+	if err := config.DB.Where("id IN ? AND board_id IN (?)", ids, boards).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.ProjectItem, len(found))
+	for _, item := range found {
+		byID[item.ID] = item
+	}
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// ✅ Get the caller's home page: starred boards, recently viewed boards and
+// items, and unfinished items due in the coming week
+func GetHome(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var starredIDs []uint
+	err := config.DB.Model(&models.BoardStar{}).
+		Where("user_id = ?", currentUserID).
+		Order("position, id").
+		Pluck("board_id", &starredIDs).Error
+	if err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+
+	var views []models.RecentView
+	if err := config.DB.Where("user_id = ?", currentUserID).Order("viewed_at DESC").Find(&views).Error; err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+	var recentBoardIDs, recentItemIDs []uint
+	for _, v := range views {
+		if v.BoardID != nil {
+			recentBoardIDs = append(recentBoardIDs, *v.BoardID)
+		} else if v.ItemID != nil {
+			recentItemIDs = append(recentItemIDs, *v.ItemID)
+		}
+	}
+
+	starred, err := boardsInOrder(currentUserID, starredIDs)
+	if err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+	recentBoards, err := boardsInOrder(currentUserID, recentBoardIDs)
+	if err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+	recentItems, err := itemsInOrder(currentUserID, recentItemIDs)
+	if err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+
+	dueSoon := []models.ProjectItem{}
+	boardIDs, err := accessibleBoardIDs(currentUserID)
+	if err == nil && len(boardIDs) > 0 {
+		err = config.DB.
+			Where("board_id IN ? AND archived_at IS NULL", boardIDs).
+			Where("due_date IS NOT NULL AND due_date < ?", time.Now().Add(homeDueWindow)).
+			Where("NOT " + completeStatusSQL).
+			Order("due_date").
+			Limit(50).
+			Find(&dueSoon).Error
+	}
+	if err != nil {
+		return apperr.Internal("Could not fetch home", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"starred_boards": starred,
+		"recent_boards":  recentBoards,
+		"recent_items":   recentItems,
+		"due_soon":       dueSoon, // overdue items come first
+	})
+}
+
+// ✅ Star a board; new stars go to the end of the list
+func StarBoard(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	var star models.BoardStar
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND board_id = ?", currentUserID, board.ID).First(&star).Error; err == nil {
+			return nil // already starred
+		}
+		var last struct{ Position *int }
+		if err := tx.Model(&models.BoardStar{}).Select("MAX(position) AS position").Where("user_id = ?", currentUserID).Scan(&last).Error; err != nil {
+			return err
+		}
+		star = models.BoardStar{UserID: currentUserID, BoardID: board.ID}
+		if last.Position != nil {
+			star.Position = *last.Position + 1
+		}
+		return tx.Create(&star).Error
+	})
+	if err != nil {
+		return apperr.Internal("Could not star board", err)
+	}
+
+	return c.JSON(star)
+}
+
+// ✅ Unstar a board
+func UnstarBoard(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	result := config.DB.Where("user_id = ? AND board_id = ?", currentUserID, c.Params("id")).Delete(&models.BoardStar{})
+	if result.Error != nil {
+		return apperr.Internal("Could not unstar board", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.NotFound("Board is not starred")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Reorder the caller's starred boards
+func ReorderStarredBoards(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body reorderStarsRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+
+	var stars []models.BoardStar
+	if err := config.DB.Where("user_id = ?", currentUserID).Find(&stars).Error; err != nil {
+		return apperr.Internal("Could not reorder starred boards", err)
+	}
+	position := make(map[uint]int, len(body.BoardIDs))
+	for i, id := range body.BoardIDs {
+		position[id] = i
+	}
+	if len(position) != len(body.BoardIDs) || len(position) != len(stars) {
+		return apperr.Invalid("board_ids must list each starred board exactly once")
+	}
+	for _, s := range stars {
+		if _, ok := position[s.BoardID]; !ok {
+			return apperr.Invalid("board_ids must list each starred board exactly once")
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range stars {
+			stars[i].Position = position[stars[i].BoardID]
+			if err := tx.Model(&stars[i]).Update("position", stars[i].Position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return apperr.Internal("Could not reorder starred boards", err)
+	}
+
+	starred, err := boardsInOrder(currentUserID, body.BoardIDs)
+	if err != nil {
+		return apperr.Internal("Could not reorder starred boards", err)
+	}
+	return c.JSON(starred)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/config"
+)
+
+func TestSaveViewThrottles(t *testing.T) {
+	db, fake := openFakeDB(t)
+	itemID := uint(10)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	view := []string{"id", "user_id", "item_id", "viewed_at"}
+
+	fake.answer(view, []driver.Value{int64(1), int64(7), int64(10), now.Add(-30 * time.Second)})
+	saveView(db, 7, nil, &itemID, now)
+	if sent := fake.sent(); len(sent) != 1 {
+		t.Errorf("a repeat view sent %q, want only the lookup", sent)
+	}
+
+	fake.answer(view, []driver.Value{int64(1), int64(7), int64(10), now.Add(-time.Hour)})
+	fake.answer([]string{"id"}, []driver.Value{int64(1)})
+	saveView(db, 7, nil, &itemID, now)
+	if sent := fake.sent(); len(sent) != 3 || !strings.HasPrefix(sent[1], "UPDATE `recent_views`") {
+		t.Errorf("a later view sent %q, want it recorded", sent)
+	}
+}
+
+// Stars and recent views outlive access to a board; the home page must not.
+func TestHomeListsOnlyAccessibleBoards(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	if _, err := boardsInOrder(7, []uint{3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := itemsInOrder(7, []uint{10}); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.sent()
+	access := "boards.user_id = 7 OR boards.id IN (SELECT `board_id` FROM `board_members` WHERE user_id = 7"
+	if len(sent) != 2 || !strings.Contains(sent[0], access) || !strings.Contains(sent[1], access) {
+		t.Errorf("sent %q, want both lookups limited to user 7's boards", sent)
+	}
+}
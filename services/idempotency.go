@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	headerReplayed       = "Idempotent-Replayed"
+	maxIdempotencyKey    = 255
+)
+
+// idempotencyTTL is how long a key and its response are kept
+// (IDEMPOTENCY_TTL, default a day). A retry after that runs again.
+func idempotencyTTL() time.Duration {
+	ttl, err := time.ParseDuration(utils.GetEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || ttl <= 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
+
+// ✅ Idempotency-Key support for create routes, placed after the auth
+// middleware: the first request with a key runs and its response is stored
+// for the user, a retry with the same body gets that response again, and a
+// different body under the same key is refused. Without a key or a logged-in
+// user the request runs as usual.
+func Idempotency(c *fiber.Ctx) error {
+	key := c.Get(headerIdempotencyKey)
+	userID := utils.GetIDFromContext(c)
+	if key == "" || userID == 0 {
+		return c.Next()
+	}
+	if len(key) > maxIdempotencyKey {
+		return apperr.BadRequest("Idempotency-Key must be at most 255 characters")
+	}
+
+	fingerprint := requestFingerprint(c)
+	record, claimed, err := claimIdempotencyKey(userID, key, fingerprint)
+	if err != nil {
+		return apperr.Internal("Could not check the Idempotency-Key", err)
+	}
+	if !claimed {
+		return replayIdempotent(c, record, fingerprint)
+	}
+
+	// Nothing was created when the request failed, so the key may be retried
+	if err := c.Next(); err != nil {
+		releaseIdempotencyKey(record)
+		return err
+	}
+	response := c.Response()
+	if response.StatusCode() >= fiber.StatusInternalServerError {
+		releaseIdempotencyKey(record)
+		return nil
+	}
+
+	record.Status = response.StatusCode()
+	record.ContentType = string(response.Header.ContentType())
+	record.Body = append([]byte(nil), response.Body()...)
+	// If this fails the key stays in progress until it expires, which is
+	// better than letting a retry create a duplicate
+	if err := config.DB.Model(record).Select("status", "content_type", "body").Updates(record).Error; err != nil {
+		log.Printf("Idempotency: could not store the response for key %q: %v", key, err)
+	}
+	return nil
+}
+
+// requestFingerprint hashes what makes two requests the same: method, path
+// with query string, and body. JSON bodies are compared as documents, so a
+// retry that orders keys differently still matches.
+func requestFingerprint(c *fiber.Ctx) string {
+	body := c.Body()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // keep numbers exactly as sent
+	var document interface{}
+	if decoder.Decode(&document) == nil {
+		if canonical, err := json.Marshal(document); err == nil {
+			body = canonical
+		}
+	}
+
+	sum := sha256.New()
+	sum.Write([]byte(c.Method() + " " + c.OriginalURL() + "\n"))
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// claimIdempotencyKey stores the key for the user unless it is already taken.
+// The unique index on (user, key) decides between concurrent requests: exactly
+// one claims the key and the others get the stored record. A nil record with
+// claimed false means the key was released while we looked.
+func claimIdempotencyKey(userID uint, key, fingerprint string) (*models.IdempotencyKey, bool, error) {
+	now := time.Now()
+
+	// An expired key is free again
+	err := config.DB.Where("user_id = ? AND idempotency_key = ? AND expires_at <= ?", userID, key, now).
+		Delete(&models.IdempotencyKey{}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(idempotencyTTL()),
+	}
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return &record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	err = config.DB.Where("user_id = ? AND idempotency_key = ?", userID, key).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// replayIdempotent answers a request whose key was already claimed.
+func replayIdempotent(c *fiber.Ctx, record *models.IdempotencyKey, fingerprint string) error {
+	if record != nil && record.Fingerprint != fingerprint {
+		return apperr.Unprocessable("This Idempotency-Key was already used with a different request").
+			WithCode(codeIdempotencyMismatch)
+	}
+	if record == nil || record.Status == 0 {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return apperr.Conflict("A request with this Idempotency-Key is still in progress").
+			WithCode(codeIdempotencyInFlight)
+	}
+
+	c.Set(headerReplayed, "true")
+	if record.ContentType != "" {
+		c.Set(fiber.HeaderContentType, record.ContentType)
+	}
+	return c.Status(record.Status).Send(record.Body)
+}
+
+// releaseIdempotencyKey frees a key whose request failed.
+func releaseIdempotencyKey(record *models.IdempotencyKey) {
+	if err := config.DB.Delete(record).Error; err != nil {
+		log.Printf("Idempotency: could not release key %q: %v", record.Key, err)
+	}
+}
+
+// StartIdempotencyPurger removes expired keys every IDEMPOTENCY_TTL.
+func StartIdempotencyPurger() {
+	ttl := idempotencyTTL()
+	go func() {
+		ticker := time.NewTicker(ttl)
+		defer ticker.Stop()
+		for range ticker.C {
+			err := config.DB.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{}).Error
+			if err != nil {
+				log.Println("Idempotency purge:", err)
+			}
+		}
+	}()
+}
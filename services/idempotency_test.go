@@ -0,0 +1,109 @@
+package services
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestReplayIdempotent(t *testing.T) {
+	stored := &models.IdempotencyKey{
+		Key:         "abc",
+		Fingerprint: "f1",
+		Status:      fiber.StatusCreated,
+		ContentType: fiber.MIMEApplicationJSON,
+		Body:        []byte(`{"id":7}`),
+	}
+	inFlight := &models.IdempotencyKey{Key: "abc", Fingerprint: "f1"}
+
+	tests := []struct {
+		name        string
+		record      *models.IdempotencyKey
+		fingerprint string
+		status      int
+		body        string // a substring of the response
+		headers     map[string]string
+	}{
+		{"replay", stored, "f1", fiber.StatusCreated, `{"id":7}`, map[string]string{headerReplayed: "true", fiber.HeaderContentType: fiber.MIMEApplicationJSON}},
+		{"different body", stored, "f2", fiber.StatusUnprocessableEntity, `"code":"idempotency_key_reused"`, nil},
+		{"in flight", inFlight, "f1", fiber.StatusConflict, `"code":"idempotency_key_in_use"`, map[string]string{fiber.HeaderRetryAfter: "1"}},
+		{"in flight with a different body", inFlight, "f2", fiber.StatusUnprocessableEntity, `"code":"idempotency_key_reused"`, nil},
+		{"released while looking", nil, "f1", fiber.StatusConflict, `"code":"idempotency_key_in_use"`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+			app.Post("/", func(c *fiber.Ctx) error {
+				return replayIdempotent(c, tt.record, tt.fingerprint)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.body) {
+				t.Errorf("got %d %s, want %d with %s", resp.StatusCode, body, tt.status, tt.body)
+			}
+			for name, want := range tt.headers {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if tt.status != fiber.StatusCreated && resp.Header.Get(headerReplayed) != "" {
+				t.Errorf("%s set on an error", headerReplayed)
+			}
+		})
+	}
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	var fingerprints []string
+	app := fiber.New()
+	app.All("/*", func(c *fiber.Ctx) error {
+		fingerprints = append(fingerprints, requestFingerprint(c))
+		return nil
+	})
+	fingerprint := func(method, path, body string) string {
+		t.Helper()
+		fingerprints = nil
+		if _, err := app.Test(httptest.NewRequest(method, path, strings.NewReader(body))); err != nil {
+			t.Fatal(err)
+		}
+		return fingerprints[0]
+	}
+
+	base := fingerprint("POST", "/items", `{"name":"Docs","board_id":1}`)
+	same := map[string][3]string{
+		"keys reordered":   {"POST", "/items", `{"board_id":1,"name":"Docs"}`},
+		"other whitespace": {"POST", "/items", "{ \"name\": \"Docs\",\n  \"board_id\": 1 }"},
+	}
+	for name, r := range same {
+		if got := fingerprint(r[0], r[1], r[2]); got != base {
+			t.Errorf("%s: fingerprint changed", name)
+		}
+	}
+	different := map[string][3]string{
+		"other value":  {"POST", "/items", `{"name":"Tests","board_id":1}`},
+		"other number": {"POST", "/items", `{"name":"Docs","board_id":1.0}`},
+		"other path":   {"POST", "/boards", `{"name":"Docs","board_id":1}`},
+		"other query":  {"POST", "/items?dry_run=true", `{"name":"Docs","board_id":1}`},
+		"other method": {"PUT", "/items", `{"name":"Docs","board_id":1}`},
+		"no body":      {"POST", "/items", ``},
+	}
+	for name, r := range different {
+		if got := fingerprint(r[0], r[1], r[2]); got == base {
+			t.Errorf("%s: fingerprint unchanged", name)
+		}
+	}
+
+	// A body that isn't JSON is hashed as sent
+	if fingerprint("POST", "/items", "name=Docs") == fingerprint("POST", "/items", "name=Tests") {
+		t.Error("form bodies with different values share a fingerprint")
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errDependencyCycle = errors.New("Dependency would create a cycle")
+
+type dependencyRequest struct {
+	BlockerID uint `json:"blocker_id" validate:"required"`
+}
+
+// wouldCreateCycle reports whether making blockerID block blockedID closes a loop,
+// i.e. blockedID already (transitively) blocks blockerID. The dependencies it
+// walks are read with FOR UPDATE, so in a transaction two inserts that would
+// close a loop together cannot both pass: the second waits for the first to
+// commit and then sees its edge, or MySQL aborts one as a deadlock.
+func wouldCreateCycle(db *gorm.DB, blockerID, blockedID uint) (bool, error) {
+	if blockerID == blockedID {
+		return true, nil
+	}
+
+	seen := map[uint]bool{blockedID: true}
+	frontier := []uint{blockedID}
+	for len(frontier) > 0 {
+		var next []uint
+		err := db.Model(&models.ItemDependency{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("blocker_id IN ?", frontier).
+			Pluck("blocked_id", &next).Error
+		if err != nil {
+			return false, err
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == blockerID {
+				return true, nil
+			}
+			if !seen[id] {
+				seen[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// enforcesBlockers reports whether the board refuses to finish items whose
+// blockers are still open.
+func enforcesBlockers(db *gorm.DB, boardID uint) (bool, error) {
+	var board models.Board
+	err := db.Select("id", "enforce_blockers").First(&board, boardID).Error
+	return board.EnforceBlockers, err
+}
+
+// openBlockers returns the IDs of unfinished items that block itemID.
+func openBlockers(db *gorm.DB, itemID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.ItemDependency{}).
+		Joins("JOIN project_items ON project_items.id = item_dependencies.blocker_id AND project_items.deleted_at IS NULL").
+		Where("item_dependencies.blocked_id = ? AND NOT "+completeStatusSQL, itemID).
+		Pluck("item_dependencies.blocker_id", &ids).Error
+	return ids, err
+}
+
+// attachDependencies fills in blocked_by and blocks for every item.
+func attachDependencies(items []models.ProjectItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	index := make(map[uint]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		index[items[i].ID] = i
+		items[i].BlockedBy = []uint{}
+		items[i].Blocks = []uint{}
+	}
+
+	var deps []models.ItemDependency
+	err := config.DB.
+		Joins("JOIN project_items AS blocker ON blocker.id = item_dependencies.blocker_id AND blocker.deleted_at IS NULL").
+		Joins("JOIN project_items AS blocked ON blocked.id = item_dependencies.blocked_id AND blocked.deleted_at IS NULL").
+		Where("item_dependencies.blocker_id IN ? OR item_dependencies.blocked_id IN ?", ids, ids).
+		Find(&deps).Error
+	if err != nil {
+		return err
+	}
+
+	for _, dep := range deps {
+		if i, ok := index[dep.BlockedID]; ok {
+			items[i].BlockedBy = append(items[i].BlockedBy, dep.BlockerID)
+		}
+		if i, ok := index[dep.BlockerID]; ok {
+			items[i].Blocks = append(items[i].Blocks, dep.BlockedID)
+		}
+	}
+	return nil
+}
+
+// decorateItems fills in every computed field on items before they are returned.
+func decorateItems(items []models.ProjectItem) error {
+	if err := attachSubtaskSummaries(items); err != nil {
+		return err
+	}
+	if err := attachDependencies(items); err != nil {
+		return err
+	}
+	if err := attachTimeSpent(items); err != nil {
+		return err
+	}
+	return attachCustomFields(items)
+}
+
+// ✅ Add a blocker to an item
+func AddItemDependency(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var item models.ProjectItem
+	if err := config.DB.First(&item, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Item not found")
+	}
+
+	var body dependencyRequest
+	if err := c.BodyParser(&body); err != nil || body.BlockerID == 0 {
+		return apperr.Invalid("blocker_id is required", apperr.Field("blocker_id", "is required"))
+	}
+
+	var blocker models.ProjectItem
+	if err := config.DB.First(&blocker, body.BlockerID).Error; err != nil {
+		return apperr.NotFound("Blocking item not found")
+	}
+
+	// Dependencies may cross boards, but only boards the caller can access.
+	if !canAccessBoard(currentUserID, item.BoardID) || !canAccessBoard(currentUserID, blocker.BoardID) {
+		return apperr.Forbidden("You do not have access to both boards")
+	}
+
+	dep := models.ItemDependency{BlockerID: blocker.ID, BlockedID: item.ID}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		cycle, err := wouldCreateCycle(tx, blocker.ID, item.ID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return errDependencyCycle
+		}
+		return tx.Where(dep).FirstOrCreate(&dep).Error
+	})
+	if errors.Is(err, errDependencyCycle) {
+		return apperr.Conflict(err.Error())
+	}
+	if err != nil {
+		return apperr.Internal("Could not add dependency", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dep)
+}
+
+// ✅ Remove a blocker from an item
+func RemoveItemDependency(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var item models.ProjectItem
+	if err := config.DB.First(&item, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Item not found")
+	}
+	if !canAccessBoard(currentUserID, item.BoardID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	blockerID, err := strconv.ParseUint(c.Params("blockerId"), 10, 64)
+	if err != nil {
+		return apperr.Invalid("Invalid ID format")
+	}
+
+	result := config.DB.Where("blocker_id = ? AND blocked_id = ?", uint(blockerID), item.ID).Delete(&models.ItemDependency{})
+	if result.Error != nil {
+		return apperr.Internal("Could not remove dependency", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.NotFound("Dependency not found")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestWouldCreateCycle(t *testing.T) {
+	db, fake := openFakeDB(t)
+	if cycle, err := wouldCreateCycle(db, 4, 4); !cycle || err != nil {
+		t.Errorf("an item blocking itself: cycle = %v, %v", cycle, err)
+	}
+
+	// 2 blocks 3 and 3 blocks 1, so 1 blocking 2 closes the loop
+	fake.answer([]string{"blocked_id"}, []driver.Value{int64(3)})
+	fake.answer([]string{"blocked_id"}, []driver.Value{int64(1)})
+	if cycle, err := wouldCreateCycle(db, 1, 2); !cycle || err != nil {
+		t.Errorf("1 -> 2 -> 3 -> 1: cycle = %v, %v", cycle, err)
+	}
+	// Every step is a locking read, so a concurrent insert waits for it
+	for _, statement := range fake.sent() {
+		if !strings.HasSuffix(statement, "FOR UPDATE") {
+			t.Errorf("walked without locking: %s", statement)
+		}
+	}
+
+	fake.answer([]string{"blocked_id"}, []driver.Value{int64(3)})
+	fake.answer([]string{"blocked_id"})
+	if cycle, err := wouldCreateCycle(db, 1, 2); cycle || err != nil {
+		t.Errorf("1 -> 2 -> 3: cycle = %v, %v", cycle, err)
+	}
+}
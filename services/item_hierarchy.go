@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxItemDepth is the deepest a subtask tree may go; a top-level item has depth 1.
+var maxItemDepth = parseEnvInt("ITEM_MAX_DEPTH", 3)
+
+var (
+	errParentNotFound   = errors.New("Parent item not found")
+	errParentOtherBoard = errors.New("Parent item must belong to the same board")
+	errParentCycle      = errors.New("An item cannot be nested under itself or one of its subtasks")
+	errParentTooDeep    = errors.New("Subtask depth limit exceeded")
+)
+
+func parseEnvInt(key string, fallback int) int {
+	n, err := strconv.Atoi(utils.GetEnv(key, strconv.Itoa(fallback)))
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+// validateParent checks that parentID can become the parent of the item
+// identified by itemID (0 for an item that is not created yet).
+func validateParent(db *gorm.DB, itemID, boardID, parentID uint) error {
+	var parent models.ProjectItem
+	if err := db.First(&parent, parentID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return errParentNotFound
+	} else if err != nil {
+		return err
+	}
+	if parent.BoardID != boardID {
+		return errParentOtherBoard
+	}
+
+	// Walk up from the new parent; meeting the item itself means a cycle.
+	depth := 1
+	current := parent
+	for {
+		if itemID != 0 && current.ID == itemID {
+			return errParentCycle
+		}
+		if current.ParentID == nil {
+			break
+		}
+		depth++
+		if depth > maxItemDepth {
+			return errParentTooDeep
+		}
+		var next models.ProjectItem
+		if err := db.First(&next, *current.ParentID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		} else if err != nil {
+			return err
+		}
+		current = next
+	}
+
+	height := 1
+	if itemID != 0 {
+		h, err := subtreeHeight(db, itemID)
+		if err != nil {
+			return err
+		}
+		height = h
+	}
+
+	if depth+height > maxItemDepth {
+		return errParentTooDeep
+	}
+	return nil
+}
+
+// parentError is the response for a validateParent failure. failed describes
+// the request for errors that aren't the client's.
+func parentError(err error, failed string) error {
+	switch {
+	case errors.Is(err, errParentNotFound), errors.Is(err, errParentOtherBoard),
+		errors.Is(err, errParentCycle), errors.Is(err, errParentTooDeep):
+		return apperr.Invalid(err.Error(), apperr.Field("parent_id", err.Error()))
+	}
+	return apperr.Internal(failed, err)
+}
+
+// subtreeHeight returns the number of levels in the tree rooted at itemID.
+func subtreeHeight(db *gorm.DB, itemID uint) (int, error) {
+	height := 0
+	level := []uint{itemID}
+	for len(level) > 0 {
+		height++
+		var next []uint
+		if err := db.Model(&models.ProjectItem{}).Where("parent_id IN ?", level).Pluck("id", &next).Error; err != nil {
+			return 0, err
+		}
+		level = next
+	}
+	return height, nil
+}
+
+// descendantIDs returns the IDs of every item below itemID.
+func descendantIDs(db *gorm.DB, itemID uint) ([]uint, error) {
+	var all []uint
+	level := []uint{itemID}
+	for len(level) > 0 {
+		var next []uint
+		if err := db.Model(&models.ProjectItem{}).Where("parent_id IN ?", level).Pluck("id", &next).Error; err != nil {
+			return nil, err
+		}
+		all = append(all, next...)
+		level = next
+	}
+	return all, nil
+}
+
+// attachSubtaskSummaries fills in the child counts of every item that has subtasks.
+func attachSubtaskSummaries(items []models.ProjectItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+
+	var rows []struct {
+		ParentID      uint
+		Total         int
+		Done          int
+		EstimateTotal float64
+		EstimateDone  float64
+	}
+	err := config.DB.Model(&models.ProjectItem{}).
+		Select("parent_id, COUNT(*) AS total, "+
+			"SUM(CASE WHEN "+completeStatusSQL+" THEN 1 ELSE 0 END) AS done, "+
+			"COALESCE(SUM(estimate), 0) AS estimate_total, "+
+			"COALESCE(SUM(CASE WHEN "+completeStatusSQL+" THEN estimate ELSE 0 END), 0) AS estimate_done").
+		Where("parent_id IN ?", ids).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[uint]*models.SubtaskSummary, len(rows))
+	for _, row := range rows {
+		summary := &models.SubtaskSummary{
+			Total:         row.Total,
+			Done:          row.Done,
+			EstimateTotal: row.EstimateTotal,
+			EstimateDone:  row.EstimateDone,
+		}
+		if row.Total > 0 {
+			summary.PercentComplete = float64(row.Done) * 100 / float64(row.Total)
+		}
+		byParent[row.ParentID] = summary
+	}
+
+	for i := range items {
+		items[i].Subtasks = byParent[items[i].ID]
+	}
+	return nil
+}
+
+// ✅ Get direct children of an item
+func GetProjectItemChildren(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var parent models.ProjectItem
+	if err := config.DB.First(&parent, id).Error; err != nil {
+		return apperr.NotFound("Item not found")
+	}
+
+	query, sort, err := itemSortOrder(c, config.DB.Where("project_items.parent_id = ?", parent.ID), parent.BoardID)
+	var page *pageRequest
+	if err == nil {
+		page, err = parsePageRequest(c, sort.listOrder, "project_items.id")
+	}
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	var children []models.ProjectItem
+	if err := query.Scopes(page.Scope).Find(&children).Error; err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	children, hasMore := trimPage(children, page.Limit)
+	if err := decorateItems(children); err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(itemPage(page, sort, children, hasMore))
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// itemFilter narrows an item listing. Values within one field are alternatives
+// (status=todo,in_progress matches either); different fields must all match.
+type itemFilter struct {
+	Boards      []uint       `json:"board,omitempty"`
+	Statuses    []string     `json:"status,omitempty"`
+	Priorities  []string     `json:"priority,omitempty"`
+	DueFrom     *time.Time   `json:"due_from,omitempty"`
+	DueTo       *time.Time   `json:"due_to,omitempty"`
+	HasDue      *bool        `json:"has_due,omitempty"`
+	Overdue     bool         `json:"overdue,omitempty"` // due in the past and not done
+	Done        *bool        `json:"done,omitempty"`    // in a complete status of the board's workflow
+	CreatedFrom *time.Time   `json:"created_from,omitempty"`
+	CreatedTo   *time.Time   `json:"created_to,omitempty"`
+	UpdatedFrom *time.Time   `json:"updated_from,omitempty"`
+	UpdatedTo   *time.Time   `json:"updated_to,omitempty"`
+	Text        string       `json:"q,omitempty"` // contained in the name or description
+	Query       *clause.Expr `json:"-"`           // compiled from ?query=, see item_query_language.go
+}
+
+// queryList collects a multi-value query parameter, given either repeated
+// (?status=a&status=b) or comma separated (?status=a,b).
+func queryList(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, raw := range c.Context().QueryArgs().PeekMulti(key) {
+		for _, v := range strings.Split(string(raw), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// queryTime reads an RFC3339 or YYYY-MM-DD query parameter. A plain date used
+// as an upper bound covers the whole day.
+func queryTime(c *fiber.Ctx, key string, upper bool) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s, use RFC3339 or YYYY-MM-DD", key)
+	}
+	if upper {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func queryBool(c *fiber.Ctx, key string) (*bool, error) {
+	switch c.Query(key) {
+	case "":
+		return nil, nil
+	case "true":
+		v := true
+		return &v, nil
+	case "false":
+		v := false
+		return &v, nil
+	}
+	return nil, fmt.Errorf("Invalid %s, use true or false", key)
+}
+
+// parseItemFilter reads the item filter query parameters: board, status,
+// priority, due_from, due_to, has_due, overdue, done, created_from,
+// created_to, updated_from, updated_to, q and query.
+func parseItemFilter(c *fiber.Ctx) (*itemFilter, error) {
+	f := &itemFilter{Text: strings.TrimSpace(c.Query("q"))}
+	if len(f.Text) > 100 {
+		return nil, errors.New("q must be at most 100 characters")
+	}
+
+	for _, v := range queryList(c, "board") {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid board %q", v)
+		}
+		f.Boards = append(f.Boards, uint(id))
+	}
+	for _, v := range queryList(c, "status") {
+		if !statusKeyPattern.MatchString(v) {
+			return nil, fmt.Errorf("Invalid status %q", v)
+		}
+		f.Statuses = append(f.Statuses, v)
+	}
+	for _, v := range queryList(c, "priority") {
+		switch models.ItemPriority(v) {
+		case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+			f.Priorities = append(f.Priorities, v)
+		default:
+			return nil, fmt.Errorf("Invalid priority %q, use low, medium or high", v)
+		}
+	}
+
+	var err error
+	times := []struct {
+		key   string
+		upper bool
+		dst   **time.Time
+	}{
+		{"due_from", false, &f.DueFrom},
+		{"due_to", true, &f.DueTo},
+		{"created_from", false, &f.CreatedFrom},
+		{"created_to", true, &f.CreatedTo},
+		{"updated_from", false, &f.UpdatedFrom},
+		{"updated_to", true, &f.UpdatedTo},
+	}
+	for _, t := range times {
+		if *t.dst, err = queryTime(c, t.key, t.upper); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.HasDue, err = queryBool(c, "has_due"); err != nil {
+		return nil, err
+	}
+	if f.Done, err = queryBool(c, "done"); err != nil {
+		return nil, err
+	}
+	overdue, err := queryBool(c, "overdue")
+	if err != nil {
+		return nil, err
+	}
+	f.Overdue = overdue != nil && *overdue
+
+	if raw := c.Query("query"); raw != "" {
+		if f.Query, err = compileItemQuery(config.DB, utils.GetIDFromContext(c), raw, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Scope applies the filter to a project_items query. Every value is bound as
+// a parameter.
+func (f *itemFilter) Scope(db *gorm.DB) *gorm.DB {
+	for _, cond := range f.conditions() {
+		db = db.Where(cond.SQL, cond.Vars...)
+	}
+	return db
+}
+
+// conditions returns the filter as SQL conditions that must all hold.
+func (f *itemFilter) conditions() []clause.Expr {
+	var conds []clause.Expr
+	where := func(sql string, vars ...interface{}) {
+		conds = append(conds, clause.Expr{SQL: sql, Vars: vars})
+	}
+
+	if len(f.Boards) > 0 {
+		where("project_items.board_id IN ?", f.Boards)
+	}
+	if len(f.Statuses) > 0 {
+		where("project_items.status IN ?", f.Statuses)
+	}
+	if len(f.Priorities) > 0 {
+		where("project_items.priority IN ?", f.Priorities)
+	}
+	if f.DueFrom != nil {
+		where("project_items.due_date >= ?", *f.DueFrom)
+	}
+	if f.DueTo != nil {
+		where("project_items.due_date <= ?", *f.DueTo)
+	}
+	if f.HasDue != nil {
+		if *f.HasDue {
+			where("project_items.due_date IS NOT NULL")
+		} else {
+			where("project_items.due_date IS NULL")
+		}
+	}
+	if f.Overdue {
+		where("project_items.due_date < ? AND NOT "+completeStatusSQL, time.Now())
+	}
+	if f.Done != nil {
+		if *f.Done {
+			where(completeStatusSQL)
+		} else {
+			where("NOT " + completeStatusSQL)
+		}
+	}
+	if f.CreatedFrom != nil {
+		where("project_items.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		where("project_items.created_at <= ?", *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		where("project_items.updated_at >= ?", *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		where("project_items.updated_at <= ?", *f.UpdatedTo)
+	}
+	if f.Text != "" {
+		pattern := "%" + escapeLike(strings.ToLower(f.Text)) + "%"
+		where("(LOWER(project_items.name) LIKE ? OR LOWER(project_items.description) LIKE ?)", pattern, pattern)
+	}
+	if f.Query != nil {
+		conds = append(conds, *f.Query)
+	}
+	return conds
+}
+
+// priorityRankSQL orders priorities from low to high.
+const priorityRankSQL = "CASE project_items.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END"
+
+var priorityRank = map[models.ItemPriority]float64{
+	models.PriorityLow:    1,
+	models.PriorityMedium: 2,
+	models.PriorityHigh:   3,
+}
+
+// itemSort is the order of an item listing, with a way to read the sort value
+// back from an item for the next page's cursor.
+type itemSort struct {
+	listOrder
+	value func(item *models.ProjectItem) interface{} // nil when ordering by id
+}
+
+// itemSortOrder reads ?sort= for an item listing: id, name, status, priority,
+// due_date, created_at, updated_at, estimate or cf.<key> (custom fields need a
+// board), ascending or with a leading - for descending.
+func itemSortOrder(c *fiber.Ctx, query *gorm.DB, boardID uint) (*gorm.DB, *itemSort, error) {
+	return itemSortBy(c.Query("sort"), query, boardID)
+}
+
+// itemSortBy is itemSortOrder for a sort given by name.
+func itemSortBy(name string, query *gorm.DB, boardID uint) (*gorm.DB, *itemSort, error) {
+	field := strings.TrimPrefix(name, "-")
+	sort := &itemSort{listOrder: listOrder{Name: name, Desc: strings.HasPrefix(name, "-")}}
+
+	switch {
+	case field == "" || field == "id":
+		return query, sort, nil
+
+	case field == "name":
+		sort.Column, sort.Kind = "project_items.name", sortText
+		sort.value = func(item *models.ProjectItem) interface{} { return item.Name }
+		return query, sort, nil
+
+	case field == "status":
+		sort.Column, sort.Kind = "project_items.status", sortText
+		sort.value = func(item *models.ProjectItem) interface{} { return string(item.Status) }
+		return query, sort, nil
+
+	case field == "priority":
+		sort.Column, sort.Kind = priorityRankSQL, sortNumber
+		sort.value = func(item *models.ProjectItem) interface{} {
+			if rank, ok := priorityRank[item.Priority]; ok {
+				return rank
+			}
+			return nil
+		}
+		return query, sort, nil
+
+	case field == "due_date":
+		sort.Column, sort.Kind = "project_items.due_date", sortTime
+		sort.value = func(item *models.ProjectItem) interface{} {
+			if item.DueDate == nil {
+				return nil
+			}
+			return *item.DueDate
+		}
+		return query, sort, nil
+
+	case field == "created_at":
+		sort.Column, sort.Kind = "project_items.created_at", sortTime
+		sort.value = func(item *models.ProjectItem) interface{} { return item.CreatedAt }
+		return query, sort, nil
+
+	case field == "updated_at":
+		sort.Column, sort.Kind = "project_items.updated_at", sortTime
+		sort.value = func(item *models.ProjectItem) interface{} { return item.UpdatedAt }
+		return query, sort, nil
+
+	case field == "estimate":
+		sort.Column, sort.Kind = "project_items.estimate", sortNumber
+		sort.value = func(item *models.ProjectItem) interface{} {
+			if item.Estimate == nil {
+				return nil
+			}
+			return *item.Estimate
+		}
+		return query, sort, nil
+
+	case strings.HasPrefix(field, "cf."):
+		key := strings.TrimPrefix(field, "cf.")
+		var cf models.CustomField
+		if boardID == 0 || config.DB.Where("board_id = ? AND field_key = ?", boardID, key).First(&cf).Error != nil {
+			return nil, nil, fmt.Errorf("Unknown custom field %q", key)
+		}
+		query = query.Joins("LEFT JOIN custom_field_values AS cf_sort ON cf_sort.item_id = project_items.id AND cf_sort.field_id = ?", cf.ID)
+		switch cf.Type {
+		case models.FieldNumber:
+			sort.Column, sort.Kind = "cf_sort.number_value", sortNumber
+		case models.FieldDate:
+			sort.Column, sort.Kind = "cf_sort.date_value", sortTime
+		default:
+			sort.Column, sort.Kind = "cf_sort.text_value", sortText
+		}
+		sort.value = func(item *models.ProjectItem) interface{} {
+			v := item.CustomFields[key]
+			if chosen, ok := v.([]string); ok {
+				encoded, _ := json.Marshal(chosen) // stored as JSON text
+				return string(encoded)
+			}
+			return v
+		}
+		return query, sort, nil
+	}
+	return nil, nil, fmt.Errorf("Unknown sort field %q", field)
+}
+
+// itemPage wraps a page of decorated items in the list envelope.
+func itemPage(page *pageRequest, sort *itemSort, items []models.ProjectItem, hasMore bool) Page {
+	if len(items) == 0 {
+		return page.result(items, hasMore, nil, 0)
+	}
+	last := &items[len(items)-1]
+	var value interface{}
+	if sort.value != nil {
+		value = sort.value(last)
+	}
+	return page.result(items, hasMore, value, last.ID)
+}
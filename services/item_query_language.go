@@ -0,0 +1,535 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The item query language, given as ?query= on item listings:
+//
+//	status:in_progress priority:high due:<7d board:"Website" -is:done
+//
+// Terms next to each other must all match, OR between terms matches either,
+// a leading - negates a term or group and parentheses group terms. Words
+// without a field, quoted or not, match the item name or description.
+//
+// Fields:
+//
+//	status:todo,in_progress   any of the statuses
+//	priority:high,medium      any of the priorities
+//	board:12 board:"Website"  board ID or name
+//	due: created: updated:    a date, compared with <, <=, >, >= or = (the default)
+//	is:overdue is:done is:open
+//	has:due
+//	label: assignee:          accepted, but items have neither yet
+//
+// Dates are YYYY-MM-DD, RFC3339, today, tomorrow, yesterday, now, or an offset
+// from now such as 7d, -2w or 12h (h, d and w units). due:<7d is anything due
+// in the next week, or overdue; created:>=-30d is anything from the last month.
+
+const maxItemQueryLength = 500
+
+// querySyntaxError is a problem in a query, at a 1-based character position.
+type querySyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *querySyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+type queryTokenKind int
+
+const (
+	tokenTerm queryTokenKind = iota
+	tokenNot
+	tokenOr
+	tokenOpen
+	tokenClose
+	tokenEnd
+)
+
+type queryToken struct {
+	kind     queryTokenKind
+	pos      int
+	field    string // empty for free text
+	value    string
+	valuePos int
+	quoted   bool
+}
+
+type queryLexer struct {
+	src    string
+	offset int // byte offset into src
+}
+
+func (l *queryLexer) pos() int {
+	return utf8.RuneCountInString(l.src[:l.offset]) + 1
+}
+
+func (l *queryLexer) errorf(pos int, format string, args ...interface{}) error {
+	return &querySyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// quoted reads a double quoted string starting at the opening quote. Inside
+// it, \" is a quote and \\ a backslash.
+func (l *queryLexer) quoted() (string, error) {
+	start := l.pos()
+	var b strings.Builder
+	for i := l.offset + 1; i < len(l.src); i++ {
+		switch ch := l.src[i]; {
+		case ch == '\\' && i+1 < len(l.src):
+			i++
+			b.WriteByte(l.src[i])
+		case ch == '"':
+			l.offset = i + 1
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", l.errorf(start, "Unterminated quote")
+}
+
+func isQueryBreak(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+var queryFieldPattern = regexp.MustCompile(`^[a-z_]+$`)
+
+func (l *queryLexer) next() (queryToken, error) {
+	for l.offset < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.offset:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.offset += size
+	}
+	tok := queryToken{pos: l.pos()}
+	if l.offset >= len(l.src) {
+		tok.kind = tokenEnd
+		return tok, nil
+	}
+
+	switch l.src[l.offset] {
+	case '(':
+		l.offset++
+		tok.kind = tokenOpen
+		return tok, nil
+	case ')':
+		l.offset++
+		tok.kind = tokenClose
+		return tok, nil
+	case '-':
+		if l.offset+1 < len(l.src) {
+			if r, _ := utf8.DecodeRuneInString(l.src[l.offset+1:]); !unicode.IsSpace(r) && r != ')' {
+				l.offset++
+				tok.kind = tokenNot
+				return tok, nil
+			}
+		}
+	case '"':
+		value, err := l.quoted()
+		if err != nil {
+			return tok, err
+		}
+		tok.value, tok.valuePos, tok.quoted = value, tok.pos, true
+		return tok, nil
+	}
+
+	start := l.offset
+	for l.offset < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.offset:])
+		if isQueryBreak(r) {
+			break
+		}
+		l.offset += size
+	}
+	word := l.src[start:l.offset]
+	if word == "OR" {
+		tok.kind = tokenOr
+		return tok, nil
+	}
+
+	field, value, ok := strings.Cut(word, ":")
+	if !ok || !queryFieldPattern.MatchString(field) {
+		tok.value, tok.valuePos = word, tok.pos
+		return tok, nil
+	}
+	tok.field = field
+	tok.valuePos = tok.pos + utf8.RuneCountInString(field) + 1
+	if value == "" && l.offset < len(l.src) && l.src[l.offset] == '"' {
+		quoted, err := l.quoted()
+		if err != nil {
+			return tok, err
+		}
+		tok.value, tok.quoted = quoted, true
+		return tok, nil
+	}
+	if value == "" {
+		return tok, l.errorf(tok.valuePos, "Missing value for %s", field)
+	}
+	tok.value = value
+	return tok, nil
+}
+
+// queryNode is a parsed query: a term, or and/or/not over other nodes.
+type queryNode struct {
+	op   string // term, and, or, not
+	term queryToken
+	kids []*queryNode
+}
+
+type queryParser struct {
+	lexer queryLexer
+	peek  queryToken
+}
+
+func (p *queryParser) advance() error {
+	tok, err := p.lexer.next()
+	p.peek = tok
+	return err
+}
+
+// parseItemQuery parses a query into a tree of terms.
+func parseItemQuery(src string) (*queryNode, error) {
+	p := &queryParser{lexer: queryLexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek.kind != tokenEnd {
+		return nil, &querySyntaxError{Pos: p.peek.pos, Msg: "Unexpected )"}
+	}
+	return node, nil
+}
+
+func (p *queryParser) or() (*queryNode, error) {
+	first, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	node := &queryNode{op: "or", kids: []*queryNode{first}}
+	for p.peek.kind == tokenOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		next, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		node.kids = append(node.kids, next)
+	}
+	if len(node.kids) == 1 {
+		return first, nil
+	}
+	return node, nil
+}
+
+func (p *queryParser) and() (*queryNode, error) {
+	node := &queryNode{op: "and"}
+	for p.peek.kind != tokenEnd && p.peek.kind != tokenClose && p.peek.kind != tokenOr {
+		next, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		node.kids = append(node.kids, next)
+	}
+	switch len(node.kids) {
+	case 0:
+		return nil, &querySyntaxError{Pos: p.peek.pos, Msg: "Expected a term"}
+	case 1:
+		return node.kids[0], nil
+	}
+	return node, nil
+}
+
+func (p *queryParser) unary() (*queryNode, error) {
+	tok := p.peek
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokenNot:
+		if p.peek.kind == tokenNot {
+			return nil, &querySyntaxError{Pos: p.peek.pos, Msg: "Expected a term"}
+		}
+		kid, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{op: "not", kids: []*queryNode{kid}}, nil
+	case tokenOpen:
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek.kind != tokenClose {
+			return nil, &querySyntaxError{Pos: tok.pos, Msg: "Unclosed ("}
+		}
+		return node, p.advance()
+	case tokenTerm:
+		return &queryNode{op: "term", term: tok}, nil
+	}
+	return nil, &querySyntaxError{Pos: tok.pos, Msg: "Expected a term"}
+}
+
+var queryOffsetPattern = regexp.MustCompile(`^([+-]?)(\d{1,4})([hdw])$`)
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// queryTimeRange reads a date value as the span it covers: a whole day for
+// dates, a single instant otherwise. Offsets are instants but cover their
+// whole day when compared with =.
+func queryTimeRange(value string, now time.Time) (from, to time.Time, offset bool, err error) {
+	day := func(t time.Time) (time.Time, time.Time, bool, error) {
+		start := startOfDay(t)
+		return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond), false, nil
+	}
+
+	switch value {
+	case "today":
+		return day(now)
+	case "tomorrow":
+		return day(now.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(now.AddDate(0, 0, -1))
+	case "now":
+		return now, now, false, nil
+	}
+	if m := queryOffsetPattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		var t time.Time
+		switch m[3] {
+		case "h":
+			t = now.Add(time.Duration(n) * time.Hour)
+		case "d":
+			t = now.AddDate(0, 0, n)
+		default:
+			t = now.AddDate(0, 0, 7*n)
+		}
+		return t, t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, t, false, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return day(t)
+	}
+	return from, to, false, fmt.Errorf("Invalid date %q", value)
+}
+
+// errQueryLookup marks a query that could not be compiled because looking
+// something up failed, which is no fault of the query.
+var errQueryLookup = errors.New("query lookup failed")
+
+// queryError is the response for item listing parameters that didn't compile.
+// failed describes the request for errors that aren't the client's.
+func queryError(err error, failed string) error {
+	if errors.Is(err, errQueryLookup) {
+		return apperr.Internal(failed, err)
+	}
+	return apperr.Invalid(err.Error())
+}
+
+// compileItemQuery parses a query and turns it into a condition on
+// project_items. Board names are looked up in db, among the boards userID can
+// access.
+func compileItemQuery(db *gorm.DB, userID uint, src string, now time.Time) (*clause.Expr, error) {
+	if utf8.RuneCountInString(src) > maxItemQueryLength {
+		return nil, fmt.Errorf("query must be at most %d characters", maxItemQueryLength)
+	}
+	node, err := parseItemQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := compileQueryNode(db, userID, node, now)
+	if err != nil {
+		return nil, err
+	}
+	return &expr, nil
+}
+
+func joinExprs(exprs []clause.Expr, sep string) clause.Expr {
+	parts := make([]string, len(exprs))
+	var vars []interface{}
+	for i, e := range exprs {
+		parts[i] = "(" + e.SQL + ")"
+		vars = append(vars, e.Vars...)
+	}
+	return clause.Expr{SQL: strings.Join(parts, sep), Vars: vars}
+}
+
+func compileQueryNode(db *gorm.DB, userID uint, node *queryNode, now time.Time) (clause.Expr, error) {
+	if node.op == "term" {
+		switch node.term.field {
+		case "label", "assignee":
+			// Items have neither yet, so no item matches and the negated
+			// term matches every item.
+			return clause.Expr{SQL: "1 = 0"}, nil
+		}
+		filter, err := queryTermFilter(db, userID, node.term, now)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		return joinExprs(filter.conditions(), " AND "), nil
+	}
+
+	exprs := make([]clause.Expr, len(node.kids))
+	for i, kid := range node.kids {
+		expr, err := compileQueryNode(db, userID, kid, now)
+		if err != nil {
+			return clause.Expr{}, err
+		}
+		exprs[i] = expr
+	}
+	switch node.op {
+	case "or":
+		return joinExprs(exprs, " OR "), nil
+	case "not":
+		// An item without a due date is not due before anything, so it
+		// matches -due:<7d: NULL comparisons count as false before negating.
+		expr := exprs[0]
+		expr.SQL = "NOT COALESCE((" + expr.SQL + "), FALSE)"
+		return expr, nil
+	}
+	return joinExprs(exprs, " AND "), nil
+}
+
+// queryTermFilter turns one field:value term, or free text, into the filter
+// it stands for.
+func queryTermFilter(db *gorm.DB, userID uint, term queryToken, now time.Time) (*itemFilter, error) {
+	f := &itemFilter{}
+	valueError := func(format string, args ...interface{}) error {
+		return &querySyntaxError{Pos: term.valuePos, Msg: fmt.Sprintf(format, args...)}
+	}
+	values := strings.Split(term.value, ",")
+	if term.quoted {
+		values = []string{term.value}
+	}
+
+	switch term.field {
+	case "":
+		f.Text = term.value
+
+	case "status":
+		for _, v := range values {
+			if !statusKeyPattern.MatchString(v) {
+				return nil, valueError("Invalid status %q", v)
+			}
+			f.Statuses = append(f.Statuses, v)
+		}
+
+	case "priority":
+		for _, v := range values {
+			switch models.ItemPriority(v) {
+			case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+				f.Priorities = append(f.Priorities, v)
+			default:
+				return nil, valueError("Invalid priority %q, use low, medium or high", v)
+			}
+		}
+
+	case "board":
+		for _, v := range values {
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+				f.Boards = append(f.Boards, uint(id))
+				continue
+			}
+			// Only boards the user can see, so a name can't reveal another user's board
+			var ids []uint
+			err := db.Model(&models.Board{}).Scopes(accessibleBoards(userID)).
+				Where("LOWER(name) = ?", strings.ToLower(v)).Pluck("id", &ids).Error
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", errQueryLookup, err)
+			}
+			if len(ids) == 0 {
+				return nil, valueError("No board named %q", v)
+			}
+			f.Boards = append(f.Boards, ids...)
+		}
+
+	case "due", "created", "updated":
+		op := "="
+		value := term.value
+		for _, candidate := range []string{"<=", ">=", "<", ">", "="} {
+			if strings.HasPrefix(value, candidate) {
+				op, value = candidate, strings.TrimPrefix(value, candidate)
+				break
+			}
+		}
+		from, to, offset, err := queryTimeRange(value, now)
+		if err != nil {
+			return nil, valueError("%s", err.Error())
+		}
+		if offset && op == "=" {
+			from = startOfDay(from)
+			to = from.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+
+		lower, upper := &f.DueFrom, &f.DueTo
+		if term.field == "created" {
+			lower, upper = &f.CreatedFrom, &f.CreatedTo
+		} else if term.field == "updated" {
+			lower, upper = &f.UpdatedFrom, &f.UpdatedTo
+		}
+		switch op {
+		case "<":
+			to = from.Add(-time.Nanosecond)
+			*upper = &to
+		case "<=":
+			*upper = &to
+		case ">":
+			from = to.Add(time.Nanosecond)
+			*lower = &from
+		case ">=":
+			*lower = &from
+		default:
+			*lower, *upper = &from, &to
+		}
+
+	case "is":
+		yes, no := true, false
+		switch term.value {
+		case "overdue":
+			f.Overdue = true
+		case "done":
+			f.Done = &yes
+		case "open":
+			f.Done = &no
+		default:
+			return nil, valueError("Unknown is:%s, use overdue, done or open", term.value)
+		}
+
+	case "has":
+		if term.value != "due" {
+			return nil, valueError("Unknown has:%s, use due", term.value)
+		}
+		yes := true
+		f.HasDue = &yes
+
+	default:
+		return nil, &querySyntaxError{Pos: term.pos, Msg: fmt.Sprintf("Unknown field %q", term.field)}
+	}
+	return f, nil
+}
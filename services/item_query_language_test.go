@@ -0,0 +1,242 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// render writes a parsed query as an s-expression, terms as field:value.
+func render(n *queryNode) string {
+	if n.op == "term" {
+		term := n.term.value
+		if n.term.quoted {
+			term = `"` + term + `"`
+		}
+		if n.term.field != "" {
+			term = n.term.field + ":" + term
+		}
+		return term
+	}
+	parts := []string{n.op}
+	for _, kid := range n.kids {
+		parts = append(parts, render(kid))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestParseItemQuery(t *testing.T) {
+	tests := map[string]string{
+		"login": "login",
+		"a b c": "(and a b c)",
+		// Implicit AND binds tighter than OR
+		"a b OR c":    "(or (and a b) c)",
+		"a OR b c":    "(or a (and b c))",
+		"a OR b OR c": "(or a b c)",
+		"a (b OR c)":  "(and a (or b c))",
+		// - negates the next term or group
+		"-status:done":                 "(not status:done)",
+		"-(a OR b) c":                  "(and (not (or a b)) c)",
+		"-a OR b":                      "(or (not a) b)",
+		"-(-a)":                        "(not (not a))",
+		"((a))":                        "a",
+		`"fix login" board:"My Board"`: `(and "fix login" board:"My Board")`,
+		`board:"say \"hi\""`:           `board:"say "hi""`,
+		// A - that isn't in front of a term is just text, and so is or
+		"a - b":      "(and a - b)",
+		"pre-fix":    "pre-fix",
+		"a or b":     "(and a or b)",
+		"due:<=-2w":  "due:<=-2w",
+		"http://x.y": "http://x.y",
+	}
+	for src, want := range tests {
+		node, err := parseItemQuery(src)
+		if err != nil {
+			t.Errorf("parseItemQuery(%q): %v", src, err)
+			continue
+		}
+		if got := render(node); got != want {
+			t.Errorf("parseItemQuery(%q) = %s, want %s", src, got, want)
+		}
+	}
+}
+
+func TestItemQueryErrors(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	tests := map[string]string{
+		"status:":         "Missing value for status at position 8",
+		"é status:":       "Missing value for status at position 10", // positions count characters
+		`"abc`:            "Unterminated quote at position 1",
+		`a board:"x`:      "Unterminated quote at position 9",
+		"(a OR b":         "Unclosed ( at position 1",
+		"a)":              "Unexpected ) at position 2",
+		"()":              "Expected a term at position 2",
+		"a OR":            "Expected a term at position 5",
+		"OR a":            "Expected a term at position 1",
+		"--a":             "Expected a term at position 2",
+		"priority:urgent": `Invalid priority "urgent", use low, medium or high at position 10`,
+		"a status:todo,B": `Invalid status "B" at position 10`,
+		"due:<soon":       `Invalid date "soon" at position 5`,
+		"created:>=7y":    `Invalid date "7y" at position 9`,
+		"is:late":         "Unknown is:late, use overdue, done or open at position 4",
+		"has:owner":       "Unknown has:owner, use due at position 5",
+		"a (b nope:x)":    `Unknown field "nope" at position 6`,
+	}
+	for src, want := range tests {
+		_, err := compileItemQuery(nil, 1, src, now)
+		if err == nil || err.Error() != want {
+			t.Errorf("compileItemQuery(%q) = %v, want %q", src, err, want)
+		}
+	}
+
+	if _, err := compileItemQuery(nil, 1, strings.Repeat("a", maxItemQueryLength+1), now); err == nil {
+		t.Error("an overlong query compiled")
+	}
+}
+
+func TestQueryTimeRange(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	endOf := func(month time.Month, day int) time.Time { return at(month, day+1, 0, 0).Add(-time.Nanosecond) }
+
+	tests := []struct {
+		value    string
+		from, to time.Time
+		offset   bool
+	}{
+		{"today", at(5, 15, 0, 0), endOf(5, 15), false},
+		{"tomorrow", at(5, 16, 0, 0), endOf(5, 16), false},
+		{"yesterday", at(5, 14, 0, 0), endOf(5, 14), false},
+		{"now", now, now, false},
+		{"7d", at(5, 22, 10, 30), at(5, 22, 10, 30), true},
+		{"+1d", at(5, 16, 10, 30), at(5, 16, 10, 30), true},
+		{"-2w", at(5, 1, 10, 30), at(5, 1, 10, 30), true},
+		{"12h", at(5, 15, 22, 30), at(5, 15, 22, 30), true},
+		{"2024-06-01", at(6, 1, 0, 0), endOf(6, 1), false},
+		{"2024-06-01T08:00:00Z", at(6, 1, 8, 0), at(6, 1, 8, 0), false},
+	}
+	for _, tt := range tests {
+		from, to, offset, err := queryTimeRange(tt.value, now)
+		if err != nil || !from.Equal(tt.from) || !to.Equal(tt.to) || offset != tt.offset {
+			t.Errorf("queryTimeRange(%q) = %v, %v, %v, %v; want %v, %v, %v", tt.value, from, to, offset, err, tt.from, tt.to, tt.offset)
+		}
+	}
+
+	for _, value := range []string{"7", "7y", "d", "12345d", "2024-13-01", "soon"} {
+		if _, _, _, err := queryTimeRange(value, now); err == nil {
+			t.Errorf("queryTimeRange(%q) = nil error, want one", value)
+		}
+	}
+}
+
+func TestCompileItemQuery(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		src  string
+		sql  string
+		vars []interface{}
+	}{
+		{
+			"status:todo,doing OR -priority:high",
+			"((project_items.status IN ?)) OR (NOT COALESCE(((project_items.priority IN ?)), FALSE))",
+			[]interface{}{[]string{"todo", "doing"}, []string{"high"}},
+		},
+		{
+			// Within the next week, or overdue
+			"due:<7d",
+			"(project_items.due_date <= ?)",
+			[]interface{}{now.AddDate(0, 0, 7).Add(-time.Nanosecond)},
+		},
+		{
+			// An offset compared with = covers its whole day
+			"due:7d",
+			"(project_items.due_date >= ?) AND (project_items.due_date <= ?)",
+			[]interface{}{day(22), day(23).Add(-time.Nanosecond)},
+		},
+		{
+			"created:>=-30d board:12",
+			"((project_items.created_at >= ?)) AND ((project_items.board_id IN ?))",
+			[]interface{}{now.AddDate(0, 0, -30), []uint{12}},
+		},
+		{
+			"updated:>yesterday",
+			"(project_items.updated_at >= ?)",
+			[]interface{}{day(15)},
+		},
+		{
+			"label:bug",
+			"1 = 0",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		expr, err := compileItemQuery(nil, 1, tt.src, now)
+		if err != nil {
+			t.Errorf("compileItemQuery(%q): %v", tt.src, err)
+			continue
+		}
+		if expr.SQL != tt.sql || !reflect.DeepEqual(expr.Vars, tt.vars) {
+			t.Errorf("compileItemQuery(%q) = %s %v, want %s %v", tt.src, expr.SQL, expr.Vars, tt.sql, tt.vars)
+		}
+	}
+}
+
+// board:"Name" only finds boards the caller can access.
+func TestItemQueryBoardNameIsScoped(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	fake.answer([]string{"id"}, []driver.Value{int64(4)})
+	expr, err := compileItemQuery(db, 7, `board:"Website"`, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expr.Vars, []interface{}{[]uint{4}}) {
+		t.Errorf("vars = %v, want board 4", expr.Vars)
+	}
+	sent := fake.sent()
+	if len(sent) != 1 || !strings.Contains(sent[0], "boards.user_id = 7 OR boards.id IN (SELECT `board_id` FROM `board_members` WHERE user_id = 7") ||
+		!strings.Contains(sent[0], `LOWER(name) = "website"`) {
+		t.Errorf("lookup = %q, want it limited to user 7's boards", sent)
+	}
+
+	_, err = compileItemQuery(db, 7, `board:"Someone else's"`, time.Now())
+	if err == nil || err.Error() != `No board named "Someone else's" at position 7` {
+		t.Errorf("an inaccessible board: err = %v", err)
+	}
+}
+
+// A failed board lookup is the server's fault, and its message stays in the log.
+func TestItemQueryLookupFailure(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	dbErr := errors.New("Error 1205 (HY000): Lock wait timeout exceeded")
+	fake.fail(dbErr)
+
+	_, err := compileItemQuery(db, 7, `board:"Website"`, time.Now())
+	var got *apperr.Error
+	if !errors.As(queryError(err, "Could not fetch items"), &got) || got.Status != fiber.StatusInternalServerError ||
+		strings.Contains(got.Detail, "Lock wait") {
+		t.Errorf("queryError(%v) = %+v, want a 500 that hides the driver error", err, got)
+	}
+
+	_, err = compileItemQuery(db, 7, "priority:urgent", time.Now())
+	if !errors.As(queryError(err, "Could not fetch items"), &got) || got.Status != fiber.StatusBadRequest {
+		t.Errorf("queryError(%v) = %+v, want a 400", err, got)
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type recurrenceRequest struct {
+	RRule    string `json:"rrule" validate:"required"`                               // e.g. FREQ=WEEKLY;BYDAY=MO
+	Timezone string `json:"timezone"`                                                // IANA name, defaults to UTC
+	Mode     string `json:"mode" validate:"omitempty,oneof=on_complete on_schedule"` // defaults to on_complete
+}
+
+// nextOccurrence computes the due date that follows the current occurrence.
+// The second result is false when the rule has no more occurrences.
+func nextOccurrence(series *models.ItemRecurrence, current time.Time) (time.Time, bool, error) {
+	rule, err := utils.ParseRRule(series.RRule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	next, ok := rule.Next(series.DTStart.In(loc), current.In(loc))
+	return next, ok, nil
+}
+
+// spawnNextOccurrence creates the item that follows current in its series and
+// makes it the series' current item. Finished series are deactivated instead.
+func spawnNextOccurrence(tx *gorm.DB, series *models.ItemRecurrence, current *models.ProjectItem) (*models.ProjectItem, error) {
+	if !series.Active || series.CurrentItemID != current.ID || current.DueDate == nil {
+		return nil, nil
+	}
+
+	due, ok, err := nextOccurrence(series, *current.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		series.Active = false
+		return nil, tx.Save(series).Error
+	}
+
+	status, err := initialStatus(tx, current.BoardID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := models.ProjectItem{
+		Name:         current.Name,
+		BoardID:      current.BoardID,
+		Description:  current.Description,
+		Priority:     current.Priority,
+		Status:       status,
+		ParentID:     current.ParentID,
+		DueDate:      &due,
+		RecurrenceID: &series.ID,
+	}
+	if err := tx.Create(&next).Error; err != nil {
+		return nil, err
+	}
+
+	series.CurrentItemID = next.ID
+	if err := tx.Save(series).Error; err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// completeOccurrence generates the next occurrence when a recurring item is
+// finished. Call it in the transaction that finishes the item, so the two
+// can't come apart.
+func completeOccurrence(tx *gorm.DB, item *models.ProjectItem) (*models.ProjectItem, error) {
+	if item.RecurrenceID == nil {
+		return nil, nil
+	}
+	var series models.ItemRecurrence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, *item.RecurrenceID).Error; err != nil {
+		return nil, err
+	}
+	return spawnNextOccurrence(tx, &series, item)
+}
+
+// runScheduledRecurrences generates the next occurrence of every on_schedule
+// series whose current item has reached its due date.
+func runScheduledRecurrences() {
+	var ids []uint
+	if err := config.DB.Model(&models.ItemRecurrence{}).Where("active = ? AND mode = ?", true, models.RecurOnSchedule).Pluck("id", &ids).Error; err != nil {
+		log.Println("Recurrence scheduler: could not load series:", err)
+		return
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			return runScheduledRecurrence(tx, id, now)
+		})
+		if err != nil {
+			log.Printf("Recurrence scheduler: series %d: %v", id, err)
+		}
+	}
+}
+
+// runScheduledRecurrence generates the next occurrence of one series if it is
+// due. The series row is locked first, so completing its current item at the
+// same moment can't spawn a second occurrence.
+func runScheduledRecurrence(tx *gorm.DB, id uint, now time.Time) error {
+	var series models.ItemRecurrence
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("active = ? AND mode = ?", true, models.RecurOnSchedule).
+		First(&series, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // stopped or switched to on_complete meanwhile
+	}
+	if err != nil {
+		return err
+	}
+
+	var current models.ProjectItem
+	err = tx.First(&current, series.CurrentItemID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The current item was deleted, so the series has nothing to follow
+		return tx.Model(&series).Update("active", false).Error
+	}
+	if err != nil {
+		return err
+	}
+	if current.DueDate == nil || current.DueDate.After(now) {
+		return nil
+	}
+	_, err = spawnNextOccurrence(tx, &series, &current)
+	return err
+}
+
+// StartRecurrenceScheduler runs the on_schedule generator in the background.
+func StartRecurrenceScheduler() {
+	interval, err := time.ParseDuration(utils.GetEnv("RECURRENCE_INTERVAL", "1h"))
+	if err != nil || interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runScheduledRecurrences()
+		}
+	}()
+}
+
+// ✅ Create or edit the recurrence of an item
+func SetItemRecurrence(c *fiber.Ctx) error {
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+
+	var body recurrenceRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Invalid request body")
+	}
+	if _, err := utils.ParseRRule(body.RRule); err != nil {
+		return apperr.Invalid("Invalid rrule: "+err.Error(), apperr.Field("rrule", err.Error()))
+	}
+	if body.Timezone == "" {
+		body.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(body.Timezone); err != nil {
+		return apperr.Invalid("Invalid timezone", apperr.Field("timezone", "must be an IANA time zone name"))
+	}
+	mode := models.RecurrenceMode(body.Mode)
+	if mode == "" {
+		mode = models.RecurOnComplete
+	}
+	if mode != models.RecurOnComplete && mode != models.RecurOnSchedule {
+		return apperr.Invalid("mode must be on_complete or on_schedule", apperr.Field("mode", "must be on_complete or on_schedule"))
+	}
+
+	// The due date anchors the series: it supplies the time of day for every occurrence.
+	if item.DueDate == nil {
+		return apperr.Invalid("Item needs a due_date before it can recur")
+	}
+
+	var series models.ItemRecurrence
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if item.RecurrenceID != nil {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, *item.RecurrenceID).Error; err != nil {
+				return err
+			}
+			// Restarting from an old occurrence would leave the newer ones behind
+			if series.CurrentItemID != item.ID {
+				return apperr.Conflict("Only the series' current occurrence can change its recurrence").
+					WithCode(codeNotCurrentOccurrence).With("current_item_id", series.CurrentItemID)
+			}
+		}
+
+		// Editing restarts the rule from this occurrence.
+		series.RRule = body.RRule
+		series.Timezone = body.Timezone
+		series.Mode = mode
+		series.DTStart = *item.DueDate
+		series.CurrentItemID = item.ID
+		series.Active = true
+		if err := tx.Save(&series).Error; err != nil {
+			return err
+		}
+
+		item.RecurrenceID = &series.ID
+		return tx.Model(item).Update("recurrence_id", series.ID).Error
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not save recurrence", err)
+	}
+
+	response := fiber.Map{"recurrence": series}
+	if next, ok, err := nextOccurrence(&series, *item.DueDate); err == nil && ok {
+		response["next_due_date"] = next
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ✅ Get the recurrence of an item
+func GetItemRecurrence(c *fiber.Ctx) error {
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+
+	var series models.ItemRecurrence
+	if item.RecurrenceID == nil || config.DB.First(&series, *item.RecurrenceID).Error != nil {
+		return apperr.NotFound("Item does not recur")
+	}
+
+	response := fiber.Map{"recurrence": series}
+	if series.Active && item.DueDate != nil {
+		if next, ok, err := nextOccurrence(&series, *item.DueDate); err == nil && ok {
+			response["next_due_date"] = next
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ✅ Stop an item from recurring; existing occurrences are kept
+func StopItemRecurrence(c *fiber.Ctx) error {
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+	if item.RecurrenceID == nil {
+		return apperr.NotFound("Item does not recur")
+	}
+
+	if err := config.DB.Model(&models.ItemRecurrence{}).Where("id = ?", *item.RecurrenceID).Update("active", false).Error; err != nil {
+		return apperr.Internal("Could not stop recurrence", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestRunScheduledRecurrence(t *testing.T) {
+	db, fake := openFakeDB(t)
+	series := []string{"id", "current_item_id", "active", "mode"}
+	now := time.Now()
+
+	// Stopped since the scheduler listed it
+	fake.answer(series)
+	if err := runScheduledRecurrence(db, 7, now); err != nil {
+		t.Fatal(err)
+	}
+	if sent := fake.sent(); len(sent) != 1 || !strings.HasSuffix(sent[0], "FOR UPDATE") {
+		t.Errorf("series lookup = %q, want a single locked read", sent)
+	}
+
+	// A deleted current item ends the series instead of failing every tick
+	fake.answer(series, []driver.Value{int64(7), int64(40), true, string(models.RecurOnSchedule)})
+	fake.answer([]string{"id"})
+	if err := runScheduledRecurrence(db, 7, now); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.sent()
+	if len(sent) != 3 || !strings.Contains(sent[2], "SET `active`=false") || !strings.Contains(sent[2], "`id` = 7") {
+		t.Errorf("sent %q, want the series deactivated", sent)
+	}
+}
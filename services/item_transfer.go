@@ -0,0 +1,505 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type transferItemRequest struct {
+	BoardID         uint   `json:"board_id" validate:"required"`
+	Status          string `json:"status"`              // optional, status of the item itself on the target board
+	IncludeSubtasks *bool  `json:"include_subtasks"`    // copy only, defaults to true; a move always takes its subtasks
+	WIPOverride     string `json:"wip_override_reason"` // lets a board admin pass a hard WIP limit
+}
+
+// transferReport tells the caller how an item was fitted onto the target
+// board and what could not be carried over.
+type transferReport struct {
+	StatusMap        map[string]string `json:"status_map"`                      // source status -> target status, for every status involved
+	DroppedFields    []string          `json:"dropped_custom_fields,omitempty"` // field keys whose values were lost
+	DroppedEstimates []uint            `json:"dropped_estimates,omitempty"`     // source item IDs whose estimate did not fit
+	DetachedParent   bool              `json:"detached_from_parent,omitempty"`  // the item was a subtask and is now top-level
+}
+
+func (r *transferReport) dropField(key string) {
+	if !containsString(r.DroppedFields, key) {
+		r.DroppedFields = append(r.DroppedFields, key)
+	}
+}
+
+// itemTransfer maps items of one board onto another board's configuration.
+type itemTransfer struct {
+	src, dst    *models.Board
+	srcStatuses []models.BoardStatus
+	dstStatuses []models.BoardStatus
+	fields      map[uint]*models.CustomField // source field ID -> target field with the same key and type
+	srcFields   map[uint]*models.CustomField
+	report      transferReport
+}
+
+func newItemTransfer(tx *gorm.DB, src, dst *models.Board) (*itemTransfer, error) {
+	t := &itemTransfer{src: src, dst: dst, report: transferReport{StatusMap: map[string]string{}}}
+
+	var err error
+	if t.srcStatuses, _, err = loadWorkflow(tx, src.ID); err != nil {
+		return nil, err
+	}
+	if t.dstStatuses, _, err = loadWorkflow(tx, dst.ID); err != nil {
+		return nil, err
+	}
+
+	var srcFields, dstFields []models.CustomField
+	if err := tx.Where("board_id = ?", src.ID).Find(&srcFields).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("board_id = ?", dst.ID).Find(&dstFields).Error; err != nil {
+		return nil, err
+	}
+	t.srcFields = make(map[uint]*models.CustomField, len(srcFields))
+	t.fields = make(map[uint]*models.CustomField, len(srcFields))
+	for i := range srcFields {
+		t.srcFields[srcFields[i].ID] = &srcFields[i]
+		for j := range dstFields {
+			if dstFields[j].Key == srcFields[i].Key && dstFields[j].Type == srcFields[i].Type {
+				t.fields[srcFields[i].ID] = &dstFields[j]
+			}
+		}
+	}
+	return t, nil
+}
+
+// status maps a status onto the target workflow: the same key if it exists,
+// otherwise the first status of the same category, otherwise the initial status.
+func (t *itemTransfer) status(from models.ItemStatus) models.ItemStatus {
+	to := models.ItemStatus("")
+	if findStatus(t.dstStatuses, string(from)) != nil {
+		to = from
+	} else if s := findStatus(t.srcStatuses, string(from)); s != nil {
+		for _, candidate := range t.dstStatuses {
+			if candidate.Category == s.Category {
+				to = models.ItemStatus(candidate.Key)
+				break
+			}
+		}
+	}
+	if to == "" {
+		to = models.StatusTodo
+		for _, candidate := range t.dstStatuses {
+			if candidate.Category == models.CategoryNotStarted {
+				to = models.ItemStatus(candidate.Key)
+				break
+			}
+		}
+	}
+	t.report.StatusMap[string(from)] = string(to)
+	return to
+}
+
+// estimate keeps an estimate only when it means the same on the target board.
+func (t *itemTransfer) estimate(item *models.ProjectItem) *float64 {
+	if item.Estimate == nil {
+		return nil
+	}
+	if t.src.EstimateUnit != t.dst.EstimateUnit || validateEstimate(t.dst, item.Estimate) != nil {
+		t.report.DroppedEstimates = append(t.report.DroppedEstimates, item.ID)
+		return nil
+	}
+	return item.Estimate
+}
+
+// fieldValue re-validates a custom field value against the matching target
+// field. It returns false when the value has to be dropped.
+func (t *itemTransfer) fieldValue(value *models.CustomFieldValue) (models.CustomFieldValue, bool) {
+	src, target := t.srcFields[value.FieldID], t.fields[value.FieldID]
+	if src == nil {
+		return models.CustomFieldValue{}, false // field was deleted
+	}
+	if target == nil {
+		t.report.dropField(src.Key)
+		return models.CustomFieldValue{}, false
+	}
+	// encodeFieldValue takes values as a request body decodes them, so dates
+	// and lists make the trip through JSON first
+	var raw interface{}
+	encoded, err := json.Marshal(decodeFieldValue(src, value))
+	if err == nil {
+		err = json.Unmarshal(encoded, &raw)
+	}
+	var mapped models.CustomFieldValue
+	if err == nil {
+		mapped, err = encodeFieldValue(target, raw)
+	}
+	if err != nil {
+		t.report.dropField(src.Key)
+		return models.CustomFieldValue{}, false
+	}
+	return mapped, true
+}
+
+// move puts the items (a subtree, root first) on the target board in place.
+// Worklogs, watches and blockers stay attached since the item IDs don't change.
+// Subtasks in the trash stay on the source board, detached, so restoring them
+// can't nest them under an item on another board.
+func (t *itemTransfer) move(tx *gorm.DB, items []models.ProjectItem, rootStatus models.ItemStatus) error {
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	err := tx.Unscoped().Model(&models.ProjectItem{}).
+		Where("parent_id IN ? AND deleted_at IS NOT NULL", ids).
+		Update("parent_id", nil).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		status := rootStatus
+		if i > 0 || status == "" {
+			status = t.status(item.Status)
+		}
+		if i == 0 && item.ParentID != nil {
+			item.ParentID = nil
+			t.report.DetachedParent = true
+		}
+		item.BoardID = t.dst.ID
+		item.Status = status
+		item.Estimate = t.estimate(item)
+		err := tx.Model(item).Select("board_id", "status", "estimate", "parent_id").Updates(item).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	var values []models.CustomFieldValue
+	if err := tx.Where("item_id IN ?", ids).Find(&values).Error; err != nil {
+		return err
+	}
+	for i := range values {
+		mapped, ok := t.fieldValue(&values[i])
+		if !ok {
+			if err := tx.Delete(&values[i]).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		mapped.ID = values[i].ID
+		mapped.ItemID = values[i].ItemID
+		if err := tx.Save(&mapped).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copy creates copies of the items (a subtree, root first) on the target
+// board, with their custom field values and the blockers between them.
+func (t *itemTransfer) copy(tx *gorm.DB, items []models.ProjectItem, rootStatus models.ItemStatus) (*models.ProjectItem, error) {
+	itemIDs := make(map[uint]uint, len(items))
+	var root models.ProjectItem
+	for i, item := range sortParentsFirst(items) {
+		copied := models.ProjectItem{
+			Name:        item.Name,
+			BoardID:     t.dst.ID,
+			Description: item.Description,
+			DueDate:     item.DueDate,
+			Status:      rootStatus,
+			Priority:    item.Priority,
+			Estimate:    t.estimate(&item),
+		}
+		if i > 0 || rootStatus == "" {
+			copied.Status = t.status(item.Status)
+		}
+		if item.ParentID != nil {
+			if id, ok := itemIDs[*item.ParentID]; ok {
+				copied.ParentID = &id
+			} else {
+				t.report.DetachedParent = true
+			}
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return nil, err
+		}
+		itemIDs[item.ID] = copied.ID
+		if i == 0 {
+			root = copied
+		}
+	}
+
+	sourceIDs := make([]uint, 0, len(itemIDs))
+	for id := range itemIDs {
+		sourceIDs = append(sourceIDs, id)
+	}
+	var values []models.CustomFieldValue
+	if err := tx.Where("item_id IN ?", sourceIDs).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	for i := range values {
+		mapped, ok := t.fieldValue(&values[i])
+		if !ok {
+			continue
+		}
+		mapped.ItemID = itemIDs[values[i].ItemID]
+		if err := tx.Create(&mapped).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Values are already handled above, so no field mapping here
+	if err := copyItemRecords(tx, itemIDs, nil, true, nil); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// loadTransfer checks a move or copy request and loads the item (first), its
+// subtree and both boards. On failure it returns a nil transfer and the
+// error to respond with.
+func loadTransfer(c *fiber.Ctx, userID uint, body *transferItemRequest, withSubtasks bool) (*itemTransfer, []models.ProjectItem, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, nil, apperr.Invalid("Invalid ID format")
+	}
+	item, err := loadAccessibleItem(userID, uint(id))
+	if item == nil {
+		return nil, nil, err
+	}
+
+	var src, dst models.Board
+	if err := config.DB.First(&src, item.BoardID).Error; err != nil {
+		return nil, nil, apperr.NotFound("Board not found")
+	}
+	if err := config.DB.First(&dst, body.BoardID).Error; err != nil {
+		return nil, nil, apperr.NotFound("Target board not found")
+	}
+	if !canAccessBoard(userID, dst.ID) {
+		return nil, nil, apperr.Forbidden("You do not have access to the target board")
+	}
+
+	transfer, err := newItemTransfer(config.DB, &src, &dst)
+	if err != nil {
+		return nil, nil, apperr.Internal("Could not load board configuration", err)
+	}
+
+	items := []models.ProjectItem{*item}
+	if withSubtasks {
+		ids, err := descendantIDs(config.DB, item.ID)
+		if err == nil && len(ids) > 0 {
+			var children []models.ProjectItem
+			err = config.DB.Where("id IN ?", ids).Find(&children).Error
+			items = append(items, sortParentsFirst(children)...)
+		}
+		if err != nil {
+			return nil, nil, apperr.Internal("Could not load subtasks", err)
+		}
+	}
+
+	// The item itself may be given an explicit status on the target board
+	if body.Status != "" {
+		if err := checkStatusChange(config.DB, dst.ID, "", models.ItemStatus(body.Status)); err != nil {
+			return nil, nil, statusChangeError(err, "Could not check the status")
+		}
+	}
+	return transfer, items, nil
+}
+
+func parseTransferRequest(c *fiber.Ctx) (*transferItemRequest, error) {
+	var body transferItemRequest
+	if err := c.BodyParser(&body); err != nil || body.BoardID == 0 {
+		return nil, apperr.Invalid("board_id is required", apperr.Field("board_id", "is required"))
+	}
+	return &body, nil
+}
+
+// checkWIP applies the target board's WIP limits to the items (a subtree,
+// root first) in the transaction that moves or copies them. Subtasks land on
+// the target board too, so each status they land in must have room for all of
+// them. It returns the limits that are exceeded: soft ones to warn about and
+// hard ones a board admin passed with reason. A hard limit without that stops
+// the transfer with errWIPLimitReached, and its check comes last.
+func (t *itemTransfer) checkWIP(tx *gorm.DB, items []models.ProjectItem, rootStatus models.ItemStatus, userID uint, reason string) ([]*wipCheck, error) {
+	var landing []models.ItemStatus
+	incoming := map[models.ItemStatus]int64{}
+	for i := range items {
+		status := rootStatus
+		if i > 0 || status == "" {
+			status = t.status(items[i].Status)
+		}
+		if incoming[status] == 0 {
+			landing = append(landing, status)
+		}
+		incoming[status]++
+	}
+
+	var exceeded []*wipCheck
+	for _, status := range landing {
+		check, _, err := evaluateWIPFor(tx, t.dst.ID, status, incoming[status], userID, reason)
+		if errors.Is(err, errWIPLimitReached) {
+			return append(exceeded, check), err
+		}
+		if err != nil {
+			return nil, err
+		}
+		if check != nil {
+			exceeded = append(exceeded, check)
+		}
+	}
+	return exceeded, nil
+}
+
+// wipWarnings are the messages for the soft limits among checks.
+func wipWarnings(checks []*wipCheck) []string {
+	var warnings []string
+	for _, check := range checks {
+		if check.Policy == models.WIPSoft {
+			warnings = append(warnings, check.message())
+		}
+	}
+	return warnings
+}
+
+// recordTransferOverrides writes the audit entries for the hard limits among
+// checks, which a board admin passed with reason.
+func recordTransferOverrides(tx *gorm.DB, checks []*wipCheck, boardID, itemID, userID uint, reason string) error {
+	for _, check := range checks {
+		if check.Policy == models.WIPSoft {
+			continue
+		}
+		if err := recordWIPOverride(tx, check, boardID, itemID, userID, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferWIPError is the response for a checkWIP failure.
+func transferWIPError(checks []*wipCheck, err error) error {
+	var check *wipCheck
+	if len(checks) > 0 {
+		check = checks[len(checks)-1]
+	}
+	return wipError(check, err, "Could not check WIP limit")
+}
+
+// ✅ Move an item, with its subtasks, to another board
+func MoveProjectItem(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	body, err := parseTransferRequest(c)
+	if body == nil {
+		return err
+	}
+	transfer, items, err := loadTransfer(c, currentUserID, body, true)
+	if transfer == nil {
+		return err
+	}
+	rootStatus := models.ItemStatus(body.Status)
+	if transfer.src.ID == transfer.dst.ID {
+		return apperr.Invalid("Item is already on that board")
+	}
+
+	var wip []*wipCheck
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if wip, err = transfer.checkWIP(tx, items, rootStatus, currentUserID, body.WIPOverride); err != nil {
+			return transferWIPError(wip, err)
+		}
+		if err := transfer.move(tx, items, rootStatus); err != nil {
+			return err
+		}
+		return recordTransferOverrides(tx, wip, transfer.dst.ID, items[0].ID, currentUserID, body.WIPOverride)
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if errors.Is(err, errVersionConflict) {
+		return apperr.Conflict("An item changed while it was being moved, try again").WithCode(codeVersionConflict)
+	}
+	if err != nil {
+		return apperr.Internal("Could not move item", err)
+	}
+
+	moved := items[:1]
+	if err := decorateItems(moved); err != nil {
+		return apperr.Internal("Item moved but could not be loaded", err)
+	}
+
+	response := fiber.Map{
+		"message":     "Item moved successfully",
+		"item":        moved[0],
+		"items_moved": len(items),
+		"report":      transfer.report,
+	}
+	if warnings := wipWarnings(wip); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	return c.JSON(response)
+}
+
+// ✅ Copy an item, by default with its subtasks, to another board (or the same one)
+func CopyProjectItem(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	body, err := parseTransferRequest(c)
+	if body == nil {
+		return err
+	}
+	transfer, items, err := loadTransfer(c, currentUserID, body, boolOr(body.IncludeSubtasks, true))
+	if transfer == nil {
+		return err
+	}
+	rootStatus := models.ItemStatus(body.Status)
+
+	var copied *models.ProjectItem
+	var wip []*wipCheck
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if wip, err = transfer.checkWIP(tx, items, rootStatus, currentUserID, body.WIPOverride); err != nil {
+			return transferWIPError(wip, err)
+		}
+		if copied, err = transfer.copy(tx, items, rootStatus); err != nil {
+			return err
+		}
+		if err := watchItem(tx, currentUserID, copied.ID, models.WatchCreated); err != nil {
+			return err
+		}
+		return recordTransferOverrides(tx, wip, transfer.dst.ID, copied.ID, currentUserID, body.WIPOverride)
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not copy item", err)
+	}
+
+	created := []models.ProjectItem{*copied}
+	if err := decorateItems(created); err != nil {
+		return apperr.Internal("Item copied but could not be loaded", err)
+	}
+
+	response := fiber.Map{
+		"message":      "Item copied successfully",
+		"item":         created[0],
+		"items_copied": len(items),
+		"report":       transfer.report,
+	}
+	if warnings := wipWarnings(wip); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
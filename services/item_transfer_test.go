@@ -0,0 +1,192 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/models"
+)
+
+// testTransfer maps from a board with the default workflow onto one with
+// another workflow and other custom fields.
+func testTransfer() *itemTransfer {
+	src := &models.Board{EstimateUnit: models.EstimateHours}
+	src.ID = 1
+	dst := &models.Board{EstimateUnit: models.EstimatePoints}
+	dst.ID = 2
+
+	field := func(id uint, key string, typ models.CustomFieldType, options ...string) *models.CustomField {
+		f := &models.CustomField{Key: key, Type: typ, Options: options}
+		f.ID = id
+		return f
+	}
+	return &itemTransfer{
+		src: src, dst: dst,
+		srcStatuses: models.DefaultWorkflow,
+		dstStatuses: []models.BoardStatus{
+			{Key: "backlog", Category: models.CategoryNotStarted},
+			{Key: "review", Category: models.CategoryActive},
+			{Key: "doing", Category: models.CategoryActive},
+			{Key: "done", Category: models.CategoryComplete},
+		},
+		srcFields: map[uint]*models.CustomField{
+			10: field(10, "team", models.FieldSingleSelect, "web", "ops"),
+			11: field(11, "tags", models.FieldMultiSelect, "a", "b"),
+			12: field(12, "launch", models.FieldDate),
+			13: field(13, "size", models.FieldNumber),
+			14: field(14, "notes", models.FieldText),
+		},
+		fields: map[uint]*models.CustomField{
+			10: field(20, "team", models.FieldSingleSelect, "web"),
+			11: field(21, "tags", models.FieldMultiSelect, "a", "b", "c"),
+			12: field(22, "launch", models.FieldDate),
+			13: field(23, "size", models.FieldNumber),
+		},
+		report: transferReport{StatusMap: map[string]string{}},
+	}
+}
+
+func TestTransferStatus(t *testing.T) {
+	transfer := testTransfer()
+	tests := map[models.ItemStatus]models.ItemStatus{
+		"done":        "done",    // the same key
+		"in_progress": "review",  // the first status of the same category
+		"todo":        "backlog", // likewise
+		"gone":        "backlog", // unknown on both boards: the initial status
+	}
+	for from, want := range tests {
+		if got := transfer.status(from); got != want {
+			t.Errorf("status(%s) = %s, want %s", from, got, want)
+		}
+	}
+	want := map[string]string{"done": "done", "in_progress": "review", "todo": "backlog", "gone": "backlog"}
+	if !reflect.DeepEqual(transfer.report.StatusMap, want) {
+		t.Errorf("status map = %v, want %v", transfer.report.StatusMap, want)
+	}
+}
+
+func TestTransferEstimate(t *testing.T) {
+	transfer := testTransfer()
+	whole, half := 3.0, 2.5
+	item := func(id uint, estimate *float64) *models.ProjectItem {
+		i := &models.ProjectItem{Estimate: estimate}
+		i.ID = id
+		return i
+	}
+	// Hours don't carry over to points, whatever the number
+	if got := transfer.estimate(item(1, &whole)); got != nil {
+		t.Errorf("hours onto points = %v, want dropped", *got)
+	}
+	transfer.src.EstimateUnit = models.EstimatePoints
+	if got := transfer.estimate(item(2, &whole)); got == nil || *got != whole {
+		t.Errorf("points onto points = %v, want 3", got)
+	}
+	if got := transfer.estimate(item(3, &half)); got != nil {
+		t.Errorf("half a point = %v, want dropped", *got)
+	}
+	if got := transfer.estimate(item(4, nil)); got != nil {
+		t.Errorf("no estimate = %v", *got)
+	}
+	if want := []uint{1, 3}; !reflect.DeepEqual(transfer.report.DroppedEstimates, want) {
+		t.Errorf("dropped estimates = %v, want %v", transfer.report.DroppedEstimates, want)
+	}
+}
+
+func TestTransferFieldValue(t *testing.T) {
+	transfer := testTransfer()
+	launch := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	size := 8.0
+
+	kept := []struct {
+		value models.CustomFieldValue
+		want  models.CustomFieldValue
+	}{
+		{models.CustomFieldValue{FieldID: 10, TextValue: "web"}, models.CustomFieldValue{FieldID: 20, TextValue: "web"}},
+		{models.CustomFieldValue{FieldID: 11, TextValue: `["a","b"]`}, models.CustomFieldValue{FieldID: 21, TextValue: `["a","b"]`}},
+		{models.CustomFieldValue{FieldID: 12, DateValue: &launch}, models.CustomFieldValue{FieldID: 22, DateValue: &launch}},
+		{models.CustomFieldValue{FieldID: 13, NumberValue: &size}, models.CustomFieldValue{FieldID: 23, NumberValue: &size}},
+	}
+	for _, tt := range kept {
+		got, ok := transfer.fieldValue(&tt.value)
+		if !ok {
+			t.Errorf("field %d was dropped", tt.value.FieldID)
+			continue
+		}
+		if got.FieldID != tt.want.FieldID || got.TextValue != tt.want.TextValue ||
+			!reflect.DeepEqual(got.NumberValue, tt.want.NumberValue) ||
+			(tt.want.DateValue != nil && (got.DateValue == nil || !got.DateValue.Equal(*tt.want.DateValue))) {
+			t.Errorf("field %d = %+v, want %+v", tt.value.FieldID, got, tt.want)
+		}
+	}
+
+	dropped := []models.CustomFieldValue{
+		{FieldID: 10, TextValue: "ops"},  // not an option on the target
+		{FieldID: 14, TextValue: "hi"},   // no such field on the target
+		{FieldID: 99, TextValue: "gone"}, // the field was deleted
+	}
+	for _, value := range dropped {
+		if got, ok := transfer.fieldValue(&value); ok {
+			t.Errorf("field %d kept as %+v", value.FieldID, got)
+		}
+	}
+	if want := []string{"team", "notes"}; !reflect.DeepEqual(transfer.report.DroppedFields, want) {
+		t.Errorf("dropped fields = %v, want %v", transfer.report.DroppedFields, want)
+	}
+}
+
+// Move and copy count every item that lands in a status, subtasks included,
+// not only the root.
+func TestTransferCheckWIP(t *testing.T) {
+	db, fake := openFakeDB(t)
+	transfer := testTransfer()
+	items := []models.ProjectItem{{Status: "todo"}, {Status: "in_progress"}, {Status: "in_progress"}}
+	limit := []string{"id", "board_id", "status_key", "wip_limit", "wip_policy"}
+
+	// The root is sent to done; both subtasks map to review, which takes one more
+	fake.answer(limit) // done has no limit row
+	fake.answer(limit, []driver.Value{int64(7), int64(2), "review", int64(2), string(models.WIPHard)})
+	fake.answer([]string{"count"}, []driver.Value{int64(1)})
+	checks, err := transfer.checkWIP(db, items, "done", 9, "")
+	if !errors.Is(err, errWIPLimitReached) || len(checks) == 0 || checks[len(checks)-1].Status != "review" {
+		t.Fatalf("checks = %v, err = %v; want review to stop the transfer", checks, err)
+	}
+	if check := checks[len(checks)-1]; check.Load != 1 || !check.Exceeded {
+		t.Errorf("review check = %+v, want a load of 1 exceeded by 2 incoming", check)
+	}
+
+	// A soft limit only warns
+	fake.answer(limit, []driver.Value{int64(8), int64(2), "backlog", int64(1), string(models.WIPSoft)})
+	fake.answer([]string{"count"}, []driver.Value{int64(1)})
+	fake.answer(limit) // review has no limit now
+	checks, err = transfer.checkWIP(db, items, "", 9, "")
+	if err != nil || len(checks) != 1 {
+		t.Fatalf("checks = %v, err = %v; want one soft warning", checks, err)
+	}
+	if warnings := wipWarnings(checks); len(warnings) != 1 || !strings.Contains(warnings[0], `"backlog"`) {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+// Trashed subtasks stay behind when their parent moves, so they must not keep
+// pointing at it.
+func TestTransferMoveDetachesTrashedSubtasks(t *testing.T) {
+	db, fake := openFakeDB(t)
+	transfer := testTransfer()
+	root := models.ProjectItem{BoardID: 1, Status: "todo"}
+	root.ID = 10
+	child := models.ProjectItem{BoardID: 1, Status: "todo", ParentID: &root.ID}
+	child.ID = 11
+
+	if err := transfer.move(db, []models.ProjectItem{root, child}, ""); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.sent()
+	if len(sent) == 0 || !strings.Contains(sent[0], "SET `parent_id`=<nil>") ||
+		!strings.Contains(sent[0], "parent_id IN (10,11) AND deleted_at IS NOT NULL") {
+		t.Errorf("first statement = %q, want trashed subtasks detached", sent)
+	}
+}
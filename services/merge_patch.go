@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseMergePatch reads a JSON Merge Patch (RFC 7396) body. Only objects make
+// sense as a patch of a board or item. allowed lists the keys the caller may
+// send; anything else is rejected rather than silently ignored.
+func parseMergePatch(c *fiber.Ctx, allowed ...string) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil || patch == nil {
+		return nil, apperr.BadRequest("Body must be a JSON object (application/merge-patch+json)")
+	}
+
+	known := make(map[string]bool, len(allowed))
+	for _, key := range allowed {
+		known[key] = true
+	}
+	var unknown []apperr.FieldError
+	for key := range patch {
+		if !known[key] {
+			unknown = append(unknown, apperr.Field(key, "cannot be patched"))
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, apperr.Invalid("The patch has fields that cannot be changed", unknown...)
+	}
+	return patch, nil
+}
+
+// mergePatch applies patch to target per RFC 7396: null removes a member,
+// objects merge member by member and anything else replaces the target.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// mergeInto applies patch to the JSON form of current and decodes the result
+// into a fresh T, so removed members come back as zero values.
+func mergeInto[T any](current T, patch map[string]interface{}) (T, error) {
+	var merged T
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return merged, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return merged, err
+	}
+	if raw, err = json.Marshal(mergePatch(document, patch)); err != nil {
+		return merged, err
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(raw, &merged); errors.As(err, &typeErr) {
+		return merged, apperr.Invalid("The patch has a value of the wrong type",
+			apperr.Field(typeErr.Field, "must be "+jsonTypeName(typeErr.Type)))
+	} else if err != nil {
+		return merged, err
+	}
+	return merged, nil
+}
+
+// samePointer reports whether two optional values are both unset or equal.
+func samePointer[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameTime is samePointer for times, which only compare equal with Equal.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// jsonTypeName names a Go type the way a JSON client thinks of it.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "a number"
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+)
+
+// The examples from RFC 7396, appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch, want interface{}
+		mustUnmarshal(t, tt.target, &target)
+		mustUnmarshal(t, tt.patch, &patch)
+		mustUnmarshal(t, tt.want, &want)
+		if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("merge %s into %s = %v, want %s", tt.patch, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestMergeInto(t *testing.T) {
+	estimate := 3.0
+	current := ItemRequestPayload{Name: "Write docs", Priority: "low", Estimate: &estimate}
+
+	merged, err := mergeInto(current, map[string]interface{}{"priority": "high", "estimate": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Name != "Write docs" || merged.Priority != "high" || merged.Estimate != nil {
+		t.Errorf("merged = %+v, want the name kept, priority high and no estimate", merged)
+	}
+	if current.Estimate == nil {
+		t.Error("mergeInto changed the current value")
+	}
+
+	_, err = mergeInto(current, map[string]interface{}{"estimate": "three"})
+	var invalid *apperr.Error
+	if !errors.As(err, &invalid) || len(invalid.Fields) != 1 ||
+		invalid.Fields[0] != apperr.Field("estimate", "must be a number") {
+		t.Errorf("wrong type: err = %v, want a field error on estimate", err)
+	}
+}
+
+func mustUnmarshal(t *testing.T, s string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/openapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+var apiInfo = openapi.Info{
+	Title:       "Mini Trello API",
+	Version:     "1.0.0",
+	Description: "Boards, items and everything around them. Log in with POST /api/v1/auth/login and send the token as a bearer token. Errors are RFC 7807 problem documents with a stable code and the request ID. Boards and items have an ETag; send it back in If-Match to avoid overwriting someone else's change. Create routes take an Idempotency-Key header so retries are safe.",
+}
+
+// listOf describes the Page envelope around a list of rows.
+func listOf(rows interface{}) fiber.Map {
+	return fiber.Map{"data": rows, "next_cursor": "", "has_more": false}
+}
+
+// mergePatchType is the media type of PATCH bodies (RFC 7396).
+const mergePatchType = "application/merge-patch+json"
+
+// idempotencyKey documents the retry header of create routes.
+var idempotencyKey = []openapi.Param{{Name: "Idempotency-Key", Description: "Unique per request; a retry with the same key and body replays the first response"}}
+
+// ifMatch documents the precondition on writes of boards and items.
+var ifMatch = []openapi.Param{{Name: "If-Match", Description: "ETag of the version being changed; a stale one gets 412 with the current version"}}
+
+// boardPatch and itemPatch describe PATCH bodies: every member is optional and
+// null clears it.
+type boardPatch struct {
+	Name            *string `json:"title"`
+	Description     *string `json:"description"`
+	EstimateUnit    *string `json:"estimate_unit"`
+	EnforceBlockers *bool   `json:"enforce_blockers"`
+}
+
+type itemPatch struct {
+	Name         *string                 `json:"name"`
+	Description  *string                 `json:"description"`
+	DueDate      *string                 `json:"due_date"` // RFC3339
+	Status       *string                 `json:"status"`
+	Priority     *string                 `json:"priority"`
+	ParentID     *uint                   `json:"parent_id"`
+	Estimate     *float64                `json:"estimate"`
+	CustomFields *map[string]interface{} `json:"custom_fields"` // merged key by key
+	WIPOverride  *string                 `json:"wip_override_reason"`
+}
+
+var (
+	pageParams = []openapi.Param{
+		{Name: "limit", Description: "page size"},
+		{Name: "cursor", Description: "next_cursor from the previous page"},
+	}
+	archivedParam  = openapi.Param{Name: "archived", Description: "false (default), true or all"}
+	itemListParams = append([]openapi.Param{
+		archivedParam,
+		{Name: "query", Description: `item query language, e.g. status:in_progress due:<7d -is:done`},
+		{Name: "q", Description: "text in the name or description"},
+		{Name: "board", Description: "board IDs"},
+		{Name: "status", Description: "status keys"},
+		{Name: "priority", Description: "low, medium or high"},
+		{Name: "due_from", Description: "RFC3339 or YYYY-MM-DD"},
+		{Name: "due_to", Description: "RFC3339 or YYYY-MM-DD"},
+		{Name: "has_due", Description: "true or false"},
+		{Name: "overdue", Description: "true or false"},
+		{Name: "done", Description: "true or false"},
+		{Name: "created_from"}, {Name: "created_to"},
+		{Name: "updated_from"}, {Name: "updated_to"},
+		{Name: "estimate_min"}, {Name: "estimate_max"},
+		{Name: "has_estimate", Description: "true or false"},
+		{Name: "sort", Description: "id, name, status, priority, due_date, created_at, updated_at, estimate or cf.<key>; prefix - for descending"},
+	}, pageParams...)
+	itemResponse       = fiber.Map{"message": "", "item": models.ProjectItem{}, "warnings": []string{}}
+	transferResponse   = fiber.Map{"message": "", "item": models.ProjectItem{}, "report": transferReport{}, "warnings": []string{}}
+	recurrenceResponse = fiber.Map{"recurrence": models.ItemRecurrence{}, "next_due_date": ""}
+	workflowResponse   = fiber.Map{"statuses": []models.BoardStatus{}, "transitions": []models.StatusTransition{}}
+)
+
+// apiOperations documents every route, keyed by method and OpenAPI path.
+// The routes package tests that it matches the registered routes.
+var apiOperations = map[string]openapi.Operation{
+	"GET /openapi.json": {Summary: "This OpenAPI document", Tag: "docs", Response: map[string]interface{}{}},
+	"GET /docs":         {Summary: "API explorer", Tag: "docs"},
+
+	"POST /api/v1/auth/login":    {Summary: "Log in and get a token", Tag: "auth", Request: LoginRequest{}, Response: fiber.Map{"message": "", "token": ""}},
+	"POST /api/v1/auth/register": {Summary: "Create an account", Tag: "auth", Request: RegisterRequest{}, Status: fiber.StatusCreated, Response: fiber.Map{"id": uint(0), "first_name": "", "last_name": "", "email": "", "role": ""}},
+
+	"POST /api/v1/boards":                         {Summary: "Create a board", Tag: "boards", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: BoardRequest{}, Status: fiber.StatusCreated, Response: models.Board{}},
+	"GET /api/v1/boards":                          {Summary: "List boards", Tag: "boards", Query: append([]openapi.Param{archivedParam}, pageParams...), Response: listOf([]models.Board{})},
+	"GET /api/v1/boards/{id}":                     {Summary: "Get a board", Tag: "boards", Auth: openapi.AuthOptional, Response: models.Board{}},
+	"PUT /api/v1/boards/{id}":                     {Summary: "Update a board (owner only)", Tag: "boards", Auth: openapi.AuthRequired, Headers: ifMatch, Request: BoardRequest{}, Response: models.Board{}},
+	"PATCH /api/v1/boards/{id}":                   {Summary: "Change some of a board's fields (owner only)", Tag: "boards", Auth: openapi.AuthRequired, Headers: ifMatch, Request: boardPatch{}, RequestType: mergePatchType, Response: models.Board{}},
+	"DELETE /api/v1/boards/{id}":                  {Summary: "Move a board and its items to the trash (owner only)", Tag: "boards", Auth: openapi.AuthRequired, Headers: ifMatch, Response: fiber.Map{"message": ""}},
+	"GET /api/v1/boards/user/{id}":                {Summary: "List a user's boards", Tag: "boards", Query: append([]openapi.Param{archivedParam}, pageParams...), Response: listOf([]models.Board{})},
+	"GET /api/v1/boards/{id}/fields":              {Summary: "List a board's custom fields, all at once in position order", Tag: "custom fields", Response: []models.CustomField{}},
+	"POST /api/v1/boards/{id}/fields":             {Summary: "Define a custom field (board admins only)", Tag: "custom fields", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: customFieldRequest{}, Status: fiber.StatusCreated, Response: models.CustomField{}},
+	"PUT /api/v1/boards/{id}/fields/{fieldId}":    {Summary: "Update a custom field (board admins only)", Tag: "custom fields", Auth: openapi.AuthRequired, Request: customFieldRequest{}, Response: models.CustomField{}},
+	"DELETE /api/v1/boards/{id}/fields/{fieldId}": {Summary: "Delete a custom field and its values (board admins only)", Tag: "custom fields", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/boards/{id}/workflow":            {Summary: "Get a board's statuses and transitions", Tag: "workflow", Response: workflowResponse},
+	"PUT /api/v1/boards/{id}/workflow":            {Summary: "Replace a board's workflow (board admins only)", Tag: "workflow", Auth: openapi.AuthRequired, Request: workflowRequest{}, Response: workflowResponse},
+	"GET /api/v1/boards/{id}/wip-overrides":       {Summary: "List WIP limit overrides on a board", Tag: "workflow", Auth: openapi.AuthRequired, Query: pageParams, Response: listOf([]models.WIPOverride{})},
+	"POST /api/v1/boards/{id}/watch":              {Summary: "Watch a board", Tag: "subscriptions", Auth: openapi.AuthRequired, Request: watchRequest{}, Response: models.Watch{}},
+	"DELETE /api/v1/boards/{id}/watch":            {Summary: "Stop watching a board", Tag: "subscriptions", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"POST /api/v1/boards/{id}/duplicate":          {Summary: "Duplicate a board", Tag: "boards", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: duplicateBoardRequest{}, Status: fiber.StatusCreated, Response: models.Board{}},
+	"POST /api/v1/boards/{id}/archive":            {Summary: "Archive a board (owner only)", Tag: "boards", Auth: openapi.AuthRequired, Response: models.Board{}},
+	"POST /api/v1/boards/{id}/unarchive":          {Summary: "Bring an archived board back (owner only)", Tag: "boards", Auth: openapi.AuthRequired, Response: models.Board{}},
+	"POST /api/v1/boards/{id}/star":               {Summary: "Star a board", Tag: "home", Auth: openapi.AuthRequired, Response: models.BoardStar{}},
+	"DELETE /api/v1/boards/{id}/star":             {Summary: "Unstar a board", Tag: "home", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/boards/{id}/views":               {Summary: "List the caller's and shared views on a board", Tag: "views", Auth: openapi.AuthRequired, Query: pageParams, Response: listOf([]models.SavedView{})},
+	"POST /api/v1/boards/{id}/views":              {Summary: "Save a view on a board", Tag: "views", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: savedViewRequest{}, Status: fiber.StatusCreated, Response: models.SavedView{}},
+	"PUT /api/v1/boards/{id}/default-view":        {Summary: "Set or clear a board's default view (board admins only)", Tag: "views", Auth: openapi.AuthRequired, Request: defaultViewRequest{}, Response: models.Board{}},
+	"GET /api/v1/boards/{id}/default-view/items":  {Summary: "Run a board's default view", Tag: "views", Auth: openapi.AuthRequired, Query: append([]openapi.Param{archivedParam}, pageParams...), Response: listOf([]models.ProjectItem{})},
+	"GET /api/v1/boards/{id}/members":             {Summary: "List a board's owner and members, all at once", Tag: "members", Auth: openapi.AuthRequired, Response: []boardMember{}},
+	"POST /api/v1/boards/{id}/members":            {Summary: "Add a user to a board by email (board admins only)", Tag: "members", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: boardMemberRequest{}, Status: fiber.StatusCreated, Response: boardMember{}},
+	"PUT /api/v1/boards/{id}/members/{userId}":    {Summary: "Change a member's role (board admins only)", Tag: "members", Auth: openapi.AuthRequired, Request: memberRoleRequest{}, Response: models.BoardMember{}},
+	"DELETE /api/v1/boards/{id}/members/{userId}": {Summary: "Remove a member; members may remove themselves", Tag: "members", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+
+	"POST /api/v1/items":                                 {Summary: "Create an item", Tag: "items", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: ItemRequestPayload{}, Status: fiber.StatusCreated, Response: itemResponse},
+	"POST /api/v1/items/bulk":                            {Summary: "Change many items in one request", Tag: "items", Auth: openapi.AuthRequired, Request: bulkItemsRequest{}, Response: fiber.Map{"operation": "", "mode": "", "matched": 0, "succeeded": 0, "failed": 0, "partial": false, "results": []bulkResult{}, "move_reports": map[string]transferReport{}}},
+	"GET /api/v1/items":                                  {Summary: "List items", Tag: "items", Query: itemListParams, Response: listOf([]models.ProjectItem{})},
+	"GET /api/v1/items/{id}":                             {Summary: "Get an item", Tag: "items", Auth: openapi.AuthOptional, Response: fiber.Map{"message": "", "item": models.ProjectItem{}}},
+	"PUT /api/v1/items/{id}":                             {Summary: "Update an item; parent_id and estimate keep their value when left out", Tag: "items", Auth: openapi.AuthRequired, Headers: ifMatch, Request: ItemRequestPayload{}, Response: itemResponse},
+	"PATCH /api/v1/items/{id}":                           {Summary: "Change some of an item's fields", Tag: "items", Auth: openapi.AuthRequired, Headers: ifMatch, Request: itemPatch{}, RequestType: mergePatchType, Response: itemResponse},
+	"DELETE /api/v1/items/{id}":                          {Summary: "Move an item to the trash", Tag: "items", Auth: openapi.AuthRequired, Headers: ifMatch, Query: []openapi.Param{{Name: "children", Description: "cascade or orphan, required when the item has subtasks"}}, Status: fiber.StatusNoContent},
+	"GET /api/v1/items/board/{id}":                       {Summary: "List a board's items", Tag: "items", Query: append(itemListParams, openapi.Param{Name: "cf.<key>", Description: "custom field filter, also cf.<key>.min and cf.<key>.max"}), Response: listOf([]models.ProjectItem{})},
+	"GET /api/v1/items/{id}/children":                    {Summary: "List an item's subtasks", Tag: "items", Query: append([]openapi.Param{{Name: "sort"}}, pageParams...), Response: listOf([]models.ProjectItem{})},
+	"POST /api/v1/items/{id}/dependencies":               {Summary: "Mark another item as blocking this one", Tag: "items", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: dependencyRequest{}, Status: fiber.StatusCreated, Response: models.ItemDependency{}},
+	"DELETE /api/v1/items/{id}/dependencies/{blockerId}": {Summary: "Remove a blocker", Tag: "items", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/items/{id}/recurrence":                  {Summary: "Get an item's recurrence", Tag: "recurrence", Auth: openapi.AuthRequired, Response: recurrenceResponse},
+	"PUT /api/v1/items/{id}/recurrence":                  {Summary: "Make an item recur", Tag: "recurrence", Auth: openapi.AuthRequired, Request: recurrenceRequest{}, Response: recurrenceResponse},
+	"DELETE /api/v1/items/{id}/recurrence":               {Summary: "Stop an item recurring", Tag: "recurrence", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/items/{id}/worklogs":                    {Summary: "List time logged on an item", Tag: "worklogs", Auth: openapi.AuthRequired, Response: fiber.Map{"worklogs": []models.Worklog{}, "total_seconds": int64(0)}},
+	"POST /api/v1/items/{id}/watch":                      {Summary: "Watch an item", Tag: "subscriptions", Auth: openapi.AuthRequired, Request: watchRequest{}, Response: models.Watch{}},
+	"DELETE /api/v1/items/{id}/watch":                    {Summary: "Stop watching an item", Tag: "subscriptions", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"POST /api/v1/items/{id}/archive":                    {Summary: "Archive an item", Tag: "items", Auth: openapi.AuthRequired, Response: models.ProjectItem{}},
+	"POST /api/v1/items/{id}/unarchive":                  {Summary: "Bring an archived item back", Tag: "items", Auth: openapi.AuthRequired, Response: models.ProjectItem{}},
+	"POST /api/v1/items/{id}/move":                       {Summary: "Move an item and its subtasks to another board", Tag: "items", Auth: openapi.AuthRequired, Request: transferItemRequest{}, Response: transferResponse},
+	"POST /api/v1/items/{id}/copy":                       {Summary: "Copy an item to a board", Tag: "items", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: transferItemRequest{}, Status: fiber.StatusCreated, Response: transferResponse},
+
+	"POST /api/v1/worklogs":             {Summary: "Log time on an item", Tag: "worklogs", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: worklogRequest{}, Status: fiber.StatusCreated, Response: models.Worklog{}},
+	"DELETE /api/v1/worklogs/{id}":      {Summary: "Delete a worklog", Tag: "worklogs", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/worklogs/timer":        {Summary: "Get the caller's running timer", Tag: "worklogs", Auth: openapi.AuthRequired, Response: fiber.Map{"worklog": models.Worklog{}, "elapsed_seconds": int64(0)}},
+	"POST /api/v1/worklogs/timer/start": {Summary: "Start a timer on an item", Tag: "worklogs", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: timerRequest{}, Status: fiber.StatusCreated, Response: models.Worklog{}},
+	"POST /api/v1/worklogs/timer/stop":  {Summary: "Stop the running timer", Tag: "worklogs", Auth: openapi.AuthRequired, Response: models.Worklog{}},
+	"GET /api/v1/worklogs/report":       {Summary: "Time logged per user and item", Tag: "worklogs", Auth: openapi.AuthRequired, Query: []openapi.Param{{Name: "user_id"}, {Name: "board_id"}, {Name: "from", Description: "YYYY-MM-DD"}, {Name: "to", Description: "YYYY-MM-DD"}, {Name: "format", Description: "csv for a CSV download"}}, Response: fiber.Map{"rows": []worklogReportRow{}, "total_seconds": int64(0)}},
+
+	"GET /api/v1/subscriptions":          {Summary: "List the caller's watches and default events", Tag: "subscriptions", Auth: openapi.AuthRequired, Response: fiber.Map{"defaults": []models.WatchEvent{}, "watches": []models.Watch{}}},
+	"PUT /api/v1/subscriptions/defaults": {Summary: "Set the events the caller hears about by default", Tag: "subscriptions", Auth: openapi.AuthRequired, Request: watchRequest{}, Response: models.SubscriptionDefaults{}},
+	"PUT /api/v1/subscriptions/{id}":     {Summary: "Change the events of one watch", Tag: "subscriptions", Auth: openapi.AuthRequired, Request: watchRequest{}, Response: models.Watch{}},
+
+	"POST /api/v1/templates/boards":                  {Summary: "Save a board as a template", Tag: "templates", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: boardTemplateRequest{}, Status: fiber.StatusCreated, Response: models.BoardTemplate{}},
+	"GET /api/v1/templates/boards":                   {Summary: "List board templates", Tag: "templates", Auth: openapi.AuthRequired, Query: pageParams, Response: listOf([]models.BoardTemplate{})},
+	"GET /api/v1/templates/boards/{id}":              {Summary: "Get a board template", Tag: "templates", Auth: openapi.AuthRequired, Response: models.BoardTemplate{}},
+	"DELETE /api/v1/templates/boards/{id}":           {Summary: "Delete a board template", Tag: "templates", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"POST /api/v1/templates/boards/{id}/instantiate": {Summary: "Create a board from a template", Tag: "templates", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: instantiateTemplateRequest{}, Status: fiber.StatusCreated, Response: models.Board{}},
+	"POST /api/v1/templates/items":                   {Summary: "Create an item template", Tag: "templates", Auth: openapi.AuthRequired, Headers: idempotencyKey, Request: itemTemplateRequest{}, Status: fiber.StatusCreated, Response: models.ItemTemplate{}},
+	"GET /api/v1/templates/items":                    {Summary: "List item templates", Tag: "templates", Auth: openapi.AuthRequired, Query: append([]openapi.Param{{Name: "board_id", Description: "also include templates for this board"}}, pageParams...), Response: listOf([]models.ItemTemplate{})},
+	"DELETE /api/v1/templates/items/{id}":            {Summary: "Delete an item template", Tag: "templates", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+
+	"GET /api/v1/trash":                      {Summary: "List the caller's deleted boards and the deleted items on boards they can access", Tag: "trash", Auth: openapi.AuthRequired, Response: fiber.Map{"boards": []trashedBoard{}, "items": []trashedItem{}}},
+	"POST /api/v1/trash/boards/{id}/restore": {Summary: "Restore a deleted board (owner only)", Tag: "trash", Auth: openapi.AuthRequired, Response: models.Board{}},
+	"DELETE /api/v1/trash/boards/{id}":       {Summary: "Delete a board for good (owner only)", Tag: "trash", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"POST /api/v1/trash/items/{id}/restore":  {Summary: "Restore a deleted item (board members)", Tag: "trash", Auth: openapi.AuthRequired, Response: models.ProjectItem{}},
+	"DELETE /api/v1/trash/items/{id}":        {Summary: "Delete an item for good (board admins)", Tag: "trash", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+
+	"GET /api/v1/home":         {Summary: "Starred and recent boards, recent items and what is due soon", Tag: "home", Auth: openapi.AuthRequired, Response: fiber.Map{"starred_boards": []models.Board{}, "recent_boards": []models.Board{}, "recent_items": []models.ProjectItem{}, "due_soon": []models.ProjectItem{}}},
+	"PUT /api/v1/home/starred": {Summary: "Reorder starred boards", Tag: "home", Auth: openapi.AuthRequired, Request: reorderStarsRequest{}, Response: []models.BoardStar{}},
+
+	"GET /api/v1/search": {Summary: "Search boards and items", Tag: "search", Auth: openapi.AuthRequired, Query: []openapi.Param{{Name: "q", Description: "required"}, {Name: "limit"}}, Response: fiber.Map{"query": "", "results": []searchHit{}}},
+
+	"GET /api/v1/views/{id}":       {Summary: "Get a saved view", Tag: "views", Auth: openapi.AuthRequired, Response: models.SavedView{}},
+	"PUT /api/v1/views/{id}":       {Summary: "Update a saved view (owner only)", Tag: "views", Auth: openapi.AuthRequired, Request: savedViewRequest{}, Response: models.SavedView{}},
+	"DELETE /api/v1/views/{id}":    {Summary: "Delete a saved view (owner only)", Tag: "views", Auth: openapi.AuthRequired, Status: fiber.StatusNoContent},
+	"GET /api/v1/views/{id}/items": {Summary: "Run a saved view", Tag: "views", Auth: openapi.AuthRequired, Query: append([]openapi.Param{archivedParam}, pageParams...), Response: listOf([]models.ProjectItem{})},
+}
+
+// CheckAPIDocs compares the app's routes with the API documentation.
+// undocumented are routes with no entry, unused are entries with no route.
+func CheckAPIDocs(app *fiber.App) (undocumented, unused []string) {
+	return openapi.Check(app.GetRoutes(true), apiOperations)
+}
+
+// CheckAPIAuth compares the auth the API documentation gives each route with
+// what authOf reads from the route's handlers.
+func CheckAPIAuth(app *fiber.App, authOf func(fiber.Route) openapi.Auth) []string {
+	return openapi.CheckAuth(app.GetRoutes(true), apiOperations, authOf)
+}
+
+// ✅ OpenAPI document for every registered route
+func GetOpenAPI(c *fiber.Ctx) error {
+	return c.JSON(openapi.Generate(apiInfo, c.App().GetRoutes(true), apiOperations))
+}
+
+// ✅ API explorer
+func GetAPIDocs(c *fiber.Ctx) error {
+	c.Type("html", "utf-8")
+	return c.Send(openapi.UI)
+}
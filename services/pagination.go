@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Page size when the request has no limit, and the most a request may ask for
+var (
+	defaultPageLimit = parseEnvInt("PAGE_LIMIT_DEFAULT", 50)
+	maxPageLimit     = parseEnvInt("PAGE_LIMIT_MAX", 200)
+)
+
+var errInvalidCursor = errors.New("Invalid cursor")
+
+// Page is the envelope every list endpoint returns. Pass next_cursor back as
+// ?cursor= to get the following page; it is empty on the last page.
+type Page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// sortKind says how a sort column's values are carried in a cursor.
+type sortKind int
+
+const (
+	sortNumber sortKind = iota
+	sortTime
+	sortText
+)
+
+// listOrder is the order of a listing. Rows are always tie-broken by id in the
+// same direction, and rows without a value sort last either way.
+type listOrder struct {
+	Name   string // as given in ?sort, cursors only work with the sort they came from
+	Column string // SQL expression, empty to order by id only
+	Kind   sortKind
+	Desc   bool
+}
+
+type pageCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v,omitempty"`
+	ID    uint        `json:"id"`
+}
+
+// pageRequest is a parsed ?limit= and ?cursor= for one listing.
+type pageRequest struct {
+	Limit    int
+	order    listOrder
+	idColumn string
+	after    *pageCursor
+	value    interface{} // after.Value decoded for the sort column
+}
+
+// parsePageRequest reads limit and cursor from the request. idColumn is the
+// qualified id column of the listed table.
+func parsePageRequest(c *fiber.Ctx, order listOrder, idColumn string) (*pageRequest, error) {
+	p := &pageRequest{Limit: defaultPageLimit, order: order, idColumn: idColumn}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("limit must be a positive number")
+		}
+		p.Limit = min(n, maxPageLimit)
+	}
+
+	raw := c.Query("cursor")
+	if raw == "" {
+		return p, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cur pageCursor
+	if err := json.Unmarshal(data, &cur); err != nil || cur.ID == 0 {
+		return nil, errInvalidCursor
+	}
+	if cur.Sort != order.Name {
+		return nil, errors.New("cursor was made for a different sort")
+	}
+	if cur.Value != nil {
+		switch order.Kind {
+		case sortTime:
+			s, _ := cur.Value.(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, errInvalidCursor
+			}
+			p.value = t
+		case sortNumber:
+			n, ok := cur.Value.(float64)
+			if !ok {
+				return nil, errInvalidCursor
+			}
+			p.value = n
+		default:
+			s, ok := cur.Value.(string)
+			if !ok {
+				return nil, errInvalidCursor
+			}
+			p.value = s
+		}
+	}
+	p.after = &cur
+	return p, nil
+}
+
+// Scope orders the query, skips to the cursor and fetches one row more than
+// the limit so the handler can tell whether there is another page.
+func (p *pageRequest) Scope(db *gorm.DB) *gorm.DB {
+	direction, cmp := "ASC", ">"
+	if p.order.Desc {
+		direction, cmp = "DESC", "<"
+	}
+	col, id := p.order.Column, p.idColumn
+
+	if p.after != nil {
+		switch {
+		case col == "":
+			db = db.Where(id+" "+cmp+" ?", p.after.ID)
+		case p.value == nil:
+			db = db.Where("("+col+" IS NULL AND "+id+" "+cmp+" ?)", p.after.ID)
+		default:
+			db = db.Where("("+col+" "+cmp+" ? OR ("+col+" = ? AND "+id+" "+cmp+" ?) OR "+col+" IS NULL)",
+				p.value, p.value, p.after.ID)
+		}
+	}
+	if col != "" {
+		db = db.Order(col + " IS NULL, " + col + " " + direction)
+	}
+	return db.Order(id + " " + direction).Limit(p.Limit + 1)
+}
+
+// trimPage drops the extra row fetched by Scope and reports whether it was there.
+func trimPage[T any](rows []T, limit int) ([]T, bool) {
+	if len(rows) > limit {
+		return rows[:limit], true
+	}
+	if rows == nil {
+		rows = []T{}
+	}
+	return rows, false
+}
+
+// result wraps a page of rows in the envelope. lastValue and lastID are the
+// sort value and id of the last row.
+func (p *pageRequest) result(data interface{}, hasMore bool, lastValue interface{}, lastID uint) Page {
+	page := Page{Data: data, HasMore: hasMore}
+	if !hasMore {
+		return page
+	}
+	if t, ok := lastValue.(time.Time); ok {
+		lastValue = t.Format(time.RFC3339Nano)
+	}
+	encoded, _ := json.Marshal(pageCursor{Sort: p.order.Name, Value: lastValue, ID: lastID})
+	page.NextCursor = base64.RawURLEncoding.EncodeToString(encoded)
+	return page
+}
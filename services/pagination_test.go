@@ -0,0 +1,123 @@
+package services
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// pageRequestFor parses query as the ?limit= and ?cursor= of a request.
+func pageRequestFor(t *testing.T, order listOrder, query string) (*pageRequest, error) {
+	t.Helper()
+	var p *pageRequest
+	var err error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		p, err = parsePageRequest(c, order, "project_items.id")
+		return nil
+	})
+	if _, testErr := app.Test(httptest.NewRequest("GET", "/?"+query, nil)); testErr != nil {
+		t.Fatal(testErr)
+	}
+	return p, err
+}
+
+func TestPageLimit(t *testing.T) {
+	order := listOrder{Name: "id"}
+	tests := map[string]int{
+		"":          defaultPageLimit,
+		"limit=10":  10,
+		"limit=999": maxPageLimit,
+	}
+	for query, want := range tests {
+		p, err := pageRequestFor(t, order, query)
+		if err != nil || p.Limit != want {
+			t.Errorf("%q: limit = %v, %v; want %d", query, p, err, want)
+		}
+	}
+	for _, query := range []string{"limit=0", "limit=-1", "limit=ten"} {
+		if _, err := pageRequestFor(t, order, query); err == nil {
+			t.Errorf("%q was accepted", query)
+		}
+	}
+}
+
+// A cursor from one page picks up after its last row on the next, by sort
+// value and then id, with rows without a value last.
+func TestPageCursorKeyset(t *testing.T) {
+	db, _ := openFakeDB(t)
+	order := listOrder{Name: "-due_date", Column: "project_items.due_date", Kind: sortTime, Desc: true}
+	due := time.Date(2024, 6, 1, 9, 30, 0, 123, time.UTC)
+
+	first, err := pageRequestFor(t, order, "limit=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := first.result(nil, true, due, 7)
+	if page.NextCursor == "" || !page.HasMore {
+		t.Fatalf("page = %+v, want a next cursor", page)
+	}
+	if last := first.result(nil, false, due, 7); last.NextCursor != "" {
+		t.Errorf("the last page has a cursor: %+v", last)
+	}
+
+	next, err := pageRequestFor(t, order, "limit=2&cursor="+url.QueryEscape(page.NextCursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := next.value.(time.Time); !ok || !got.Equal(due) || next.after.ID != 7 {
+		t.Errorf("cursor decoded to %v id %d, want %v id 7", next.value, next.after.ID, due)
+	}
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var items []models.ProjectItem
+		return tx.Scopes(next.Scope).Find(&items)
+	})
+	for _, want := range []string{
+		"(project_items.due_date < '2024-06-01 09:30:00' OR (project_items.due_date = '2024-06-01 09:30:00' AND project_items.id < 7) OR project_items.due_date IS NULL)",
+		"ORDER BY project_items.due_date IS NULL, project_items.due_date DESC,project_items.id DESC LIMIT 3",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("next page query = %s\nwant %s", sql, want)
+		}
+	}
+
+	// Past the last dated row, only undated ones are left
+	undated, err := pageRequestFor(t, order, "cursor="+url.QueryEscape(first.result(nil, true, nil, 9).NextCursor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql = db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var items []models.ProjectItem
+		return tx.Scopes(undated.Scope).Find(&items)
+	})
+	if !strings.Contains(sql, "(project_items.due_date IS NULL AND project_items.id < 9)") {
+		t.Errorf("undated page query = %s", sql)
+	}
+
+	if _, err := pageRequestFor(t, listOrder{Name: "name", Column: "project_items.name", Kind: sortText}, "cursor="+url.QueryEscape(page.NextCursor)); err == nil ||
+		err.Error() != "cursor was made for a different sort" {
+		t.Errorf("a cursor for another sort: err = %v", err)
+	}
+	for _, cursor := range []string{"not-base64!", "bm9wZQ", "eyJzIjoiLWR1ZV9kYXRlIiwidiI6MSwiaWQiOjd9"} {
+		if _, err := pageRequestFor(t, order, "cursor="+url.QueryEscape(cursor)); err == nil {
+			t.Errorf("cursor %q was accepted", cursor)
+		}
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	rows, more := trimPage([]int{1, 2, 3}, 2)
+	if len(rows) != 2 || !more {
+		t.Errorf("trimPage of 3 to 2 = %v, %v", rows, more)
+	}
+	rows, more = trimPage([]int(nil), 2)
+	if rows == nil || more {
+		t.Errorf("an empty page = %#v, %v; want [] so it encodes as a list", rows, more)
+	}
+}
@@ -2,51 +2,65 @@
 package services
 
 import (
+	"errors"
 	"strconv"
+	"unicode/utf8"
 
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/clem-kay/mini-trello/config"
 	"github.com/clem-kay/mini-trello/models"
 	"github.com/clem-kay/mini-trello/utils"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-type boardRequest struct {
-	Name        string `json:"title" validate:"required,min=3,max=100"`
-	Description string `json:"description" validate:"max=255"`
+type BoardRequest struct {
+	Name            string `json:"title" validate:"required,min=3,max=100"`
+	Description     string `json:"description" validate:"max=255"`
+	EstimateUnit    string `json:"estimate_unit" validate:"omitempty,oneof=points hours"`
+	EnforceBlockers *bool  `json:"enforce_blockers"` // refuse to finish items with open blockers; off when left out
 }
 
 // ✅ CREATE
 func CreateBoard(c *fiber.Ctx) error {
 	currentUserID := utils.GetIDFromContext(c)
 	if currentUserID == 0 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized, user needs to be logged in",
-		})
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
 	}
 
-	var body boardRequest
+	var body BoardRequest
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cannot parse JSON",
-		})
+		return apperr.BadRequest("Cannot parse JSON")
 	}
 
 	if body.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Name is required",
-		})
+		return apperr.Invalid("Name is required", apperr.Field("title", "is required"))
+	}
+
+	unit, ok := parseEstimateUnit(body.EstimateUnit)
+	if !ok {
+		return apperr.Invalid("estimate_unit must be points or hours", apperr.Field("estimate_unit", "must be points or hours"))
 	}
 
 	board := models.Board{
-		Name:        body.Name,
-		Description: body.Description,
-		UserID:      currentUserID,
+		Name:            body.Name,
+		Description:     body.Description,
+		UserID:          currentUserID,
+		EstimateUnit:    unit,
+		EnforceBlockers: body.EnforceBlockers != nil && *body.EnforceBlockers,
 	}
 
-	if err := config.DB.Create(&board).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not create board: " + err.Error(), // ← Fixed: added space
-		})
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&board).Error; err != nil {
+			return err
+		}
+		if err := watchBoard(tx, currentUserID, board.ID, models.WatchCreated); err != nil {
+			return err
+		}
+		return createDefaultWorkflow(tx, board.ID)
+	})
+	if err != nil {
+		return apperr.Internal("Could not create board", err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(board)
@@ -56,141 +70,261 @@ func CreateBoard(c *fiber.Ctx) error {
 func GetBoards(c *fiber.Ctx) error {
 	var boardList []models.Board
 
-	// Optional: add pagination, limit, etc.
-	result := config.DB.Find(&boardList)
+	query, err := applyArchivedQuery(c, config.DB, "boards")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+	page, err := parsePageRequest(c, listOrder{}, "boards.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	result := query.Scopes(page.Scope).Find(&boardList)
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not fetch boards",
-		})
+		return apperr.Internal("Could not fetch boards", result.Error)
+	}
+	boardList, hasMore := trimPage(boardList, page.Limit)
+	if err := attachBoardEstimates(boardList); err != nil {
+		return apperr.Internal("Could not fetch boards", err)
 	}
 
-	return c.JSON(boardList)
+	return c.JSON(boardPage(page, boardList, hasMore))
+}
+
+// boardPage wraps a page of boards, ordered by id, in the list envelope.
+func boardPage(page *pageRequest, boards []models.Board, hasMore bool) Page {
+	var lastID uint
+	if len(boards) > 0 {
+		lastID = boards[len(boards)-1].ID
+	}
+	return page.result(boards, hasMore, nil, lastID)
 }
 
 // ✅ GET BY ID
 func GetBoardByID(c *fiber.Ctx) error {
-	idStr := utils.GetIDParam(c)
-	if idStr == "" {
-		return nil // error already sent
+	idStr, err := utils.GetIDParam(c)
+	if err != nil {
+		return err
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID format",
-		})
+		return apperr.Invalid("Invalid ID format")
 	}
 
 	var board models.Board
 	if err := config.DB.First(&board, uint(id)).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Board not found",
-		})
+		return apperr.NotFound("Board not found")
 	}
 
-	return c.JSON(board)
+	boards := []models.Board{board}
+	if err := attachBoardEstimates(boards); err != nil {
+		return apperr.Internal("Could not fetch board", err)
+	}
+
+	columns, _, err := loadWorkflow(config.DB, board.ID)
+	if err == nil {
+		err = attachStatusLoads(config.DB, board.ID, columns)
+	}
+	if err != nil {
+		return apperr.Internal("Could not fetch board", err)
+	}
+	boards[0].Columns = columns
+	recordView(utils.GetIDFromContext(c), &board.ID, nil)
+
+	setETag(c, board.Version)
+	return c.JSON(boards[0])
 }
 
-// ✅ UPDATE (only owner can update)
-func UpdateBoard(c *fiber.Ctx) error {
+// loadOwnedBoard fetches the board in the :id param if the caller owns it.
+// On failure it returns nil and the error to respond with.
+func loadOwnedBoard(c *fiber.Ctx) (*models.Board, error) {
 	currentUserID := utils.GetIDFromContext(c)
 	if currentUserID == 0 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized, user needs to be logged in",
-		})
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
 	}
 
-	idStr := utils.GetIDParam(c)
-	if idStr == "" {
-		return nil
+	idStr, err := utils.GetIDParam(c)
+	if err != nil {
+		return nil, err
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID format",
-		})
+		return nil, apperr.Invalid("Invalid ID format")
 	}
 
 	var board models.Board
 	if err := config.DB.First(&board, uint(id)).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Board not found",
-		})
+		return nil, apperr.NotFound("Board not found")
 	}
 
 	// ✅ Ownership check
 	if board.UserID != currentUserID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "You do not own this board",
-		})
+		return nil, apperr.Forbidden("You do not own this board")
+	}
+	return &board, nil
+}
+
+// staleBoard turns a version conflict into a 412 with the board as it is now.
+// Other errors pass through.
+func staleBoard(c *fiber.Ctx, id uint, err error) error {
+	if !errors.Is(err, errVersionConflict) {
+		return err
+	}
+	var current models.Board
+	if err := config.DB.First(&current, id).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	return staleWrite(c, current.Version, current)
+}
+
+// checkBoardRequest validates a board's fields as a whole, as they will be
+// stored. An empty estimate_unit means points.
+func checkBoardRequest(body *BoardRequest) error {
+	var fields []apperr.FieldError
+	if body.Name == "" {
+		fields = append(fields, apperr.Field("title", "is required"))
+	} else if utf8.RuneCountInString(body.Name) > 100 {
+		fields = append(fields, apperr.Field("title", "must be at most 100 characters long"))
+	}
+	if utf8.RuneCountInString(body.Description) > 255 {
+		fields = append(fields, apperr.Field("description", "must be at most 255 characters long"))
+	}
+	if _, ok := parseEstimateUnit(body.EstimateUnit); !ok {
+		fields = append(fields, apperr.Field("estimate_unit", "must be points or hours"))
+	}
+	if len(fields) > 0 {
+		return apperr.Invalid("Invalid board", fields...)
+	}
+	return nil
+}
+
+// ✅ UPDATE (only owner can update)
+func UpdateBoard(c *fiber.Ctx) error {
+	board, err := loadOwnedBoard(c)
+	if board == nil {
+		return err
+	}
+	if err := checkIfMatch(c, board.Version); err != nil {
+		return staleBoard(c, board.ID, err)
 	}
 
-	var body boardRequest
+	var body BoardRequest
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cannot parse JSON",
-		})
+		return apperr.BadRequest("Cannot parse JSON")
 	}
 
 	if body.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Name is required",
-		})
+		return apperr.Invalid("Name is required", apperr.Field("title", "is required"))
 	}
 
 	// Update fields
 	board.Name = body.Name
 	board.Description = body.Description
+	if body.EstimateUnit != "" {
+		unit, ok := parseEstimateUnit(body.EstimateUnit)
+		if !ok {
+			return apperr.Invalid("estimate_unit must be points or hours", apperr.Field("estimate_unit", "must be points or hours"))
+		}
+		board.EstimateUnit = unit
+	}
+	if body.EnforceBlockers != nil {
+		board.EnforceBlockers = *body.EnforceBlockers
+	}
 
-	if err := config.DB.Save(&board).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not update board: " + err.Error(),
-		})
+	err = config.DB.Save(board).Error
+	if errors.Is(err, errVersionConflict) {
+		return staleBoard(c, board.ID, err)
+	}
+	if err != nil {
+		return apperr.Internal("Could not update board", err)
 	}
 
+	setETag(c, board.Version)
 	return c.JSON(board)
 }
 
-// ✅ DELETE (only owner can delete)
-func DeleteBoard(c *fiber.Ctx) error {
-	currentUserID := utils.GetIDFromContext(c)
-	if currentUserID == 0 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized, user needs to be logged in",
-		})
+// ✅ PATCH (only owner can update): a JSON Merge Patch, so fields left out keep
+// their value and only the columns that change are written
+func PatchBoard(c *fiber.Ctx) error {
+	board, err := loadOwnedBoard(c)
+	if board == nil {
+		return err
 	}
-
-	idStr := utils.GetIDParam(c)
-	if idStr == "" {
-		return nil
+	if err := checkIfMatch(c, board.Version); err != nil {
+		return staleBoard(c, board.ID, err)
 	}
 
-	id, err := strconv.ParseUint(idStr, 10, 64)
+	patch, err := parseMergePatch(c, "title", "description", "estimate_unit", "enforce_blockers")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID format",
-		})
+		return err
 	}
+	current := BoardRequest{Name: board.Name, Description: board.Description, EstimateUnit: string(board.EstimateUnit), EnforceBlockers: &board.EnforceBlockers}
+	merged, err := mergeInto(current, patch)
+	if err != nil {
+		return err
+	}
+	if err := checkBoardRequest(&merged); err != nil {
+		return err
+	}
+	unit, _ := parseEstimateUnit(merged.EstimateUnit)
 
-	var board models.Board
-	if err := config.DB.First(&board, uint(id)).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Board not found",
-		})
+	var columns []string
+	if merged.Name != board.Name {
+		board.Name = merged.Name
+		columns = append(columns, "name")
+	}
+	if merged.Description != board.Description {
+		board.Description = merged.Description
+		columns = append(columns, "description")
+	}
+	if unit != board.EstimateUnit {
+		board.EstimateUnit = unit
+		columns = append(columns, "estimate_unit")
+	}
+	// null turns the setting off like leaving it out on create
+	if enforce := merged.EnforceBlockers != nil && *merged.EnforceBlockers; enforce != board.EnforceBlockers {
+		board.EnforceBlockers = enforce
+		columns = append(columns, "enforce_blockers")
 	}
 
-	// ✅ Ownership check
-	if board.UserID != currentUserID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "You do not own this board",
-		})
+	if len(columns) > 0 {
+		err := config.DB.Model(board).Select(columns).Updates(board).Error
+		if errors.Is(err, errVersionConflict) {
+			return staleBoard(c, board.ID, err)
+		}
+		if err != nil {
+			return apperr.Internal("Could not update board", err)
+		}
 	}
 
-	if err := config.DB.Delete(&board).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not delete board",
-		})
+	setETag(c, board.Version)
+	return c.JSON(board)
+}
+
+// ✅ DELETE (only owner can delete)
+func DeleteBoard(c *fiber.Ctx) error {
+	board, err := loadOwnedBoard(c)
+	if board == nil {
+		return err
+	}
+	if err := checkIfMatch(c, board.Version); err != nil {
+		return staleBoard(c, board.ID, err)
+	}
+
+	// Items go to the trash with their board
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimVersion(tx, &models.Board{}, board.ID, board.Version); err != nil {
+			return err
+		}
+		return softDeleteBoard(tx, board)
+	})
+	if errors.Is(err, errVersionConflict) {
+		return staleBoard(c, board.ID, err)
+	}
+	if err != nil {
+		return apperr.Internal("Could not delete board", err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -200,25 +334,35 @@ func DeleteBoard(c *fiber.Ctx) error {
 
 // ✅ GET BOARDS BY USER ID
 func GetBoardByUserID(c *fiber.Ctx) error {
-	idStr := utils.GetIDParam(c)
-	if idStr == "" {
-		return nil
+	idStr, err := utils.GetIDParam(c)
+	if err != nil {
+		return err
 	}
 
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID format",
-		})
+		return apperr.Invalid("Invalid user ID format")
+	}
+
+	query, err := applyArchivedQuery(c, config.DB.Where("user_id = ?", uint(id)), "boards")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	page, err := parsePageRequest(c, listOrder{}, "boards.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
 	}
 
 	var boards []models.Board
-	result := config.DB.Where("user_id = ?", uint(id)).Find(&boards)
+	result := query.Scopes(page.Scope).Find(&boards)
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not fetch boards",
-		})
+		return apperr.Internal("Could not fetch boards", result.Error)
+	}
+	boards, hasMore := trimPage(boards, page.Limit)
+	if err := attachBoardEstimates(boards); err != nil {
+		return apperr.Internal("Could not fetch boards", err)
 	}
 
-	return c.JSON(boards)
-}
\ No newline at end of file
+	return c.JSON(boardPage(page, boards, hasMore))
+}
@@ -1,52 +1,74 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/clem-kay/mini-trello/config"
 	"github.com/clem-kay/mini-trello/models"
 	"github.com/clem-kay/mini-trello/utils"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type ItemRequestPayload struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date"` // ISO 8601 format
-	Status      string `json:"status" validate:"oneof=todo in_progress done"`
-	Priority    string `json:"priority" validate:"oneof=low medium high"`
-	BoardID     uint   `json:"board_id" validate:"required"`
+	Name         string                 `json:"name" validate:"required"`
+	Description  string                 `json:"description"`
+	DueDate      string                 `json:"due_date"`                 // ISO 8601 format
+	Status       string                 `json:"status" validate:"max=20"` // a status key from the board's workflow
+	Priority     string                 `json:"priority" validate:"omitempty,oneof=low medium high"`
+	BoardID      uint                   `json:"board_id" validate:"required"`
+	ParentID     *uint                  `json:"parent_id"`                           // optional, nests the item under another item on the same board; on PUT, null un-nests it and leaving it out keeps the parent
+	Estimate     *float64               `json:"estimate" validate:"omitempty,min=0"` // story points or hours, per the board; on PUT, null clears it and leaving it out keeps it
+	CustomFields map[string]interface{} `json:"custom_fields"`                       // keyed by the board's field keys, null clears a value
+	WIPOverride  string                 `json:"wip_override_reason"`                 // lets a board admin pass a hard WIP limit
+	TemplateID   *uint                  `json:"template_id"`                         // create only: pre-fill from an item template
 }
 
 // ✅ Create Project Item
 func CreateProjectItem(c *fiber.Ctx) error {
 	currentUserID := utils.GetIDFromContext(c)
 	if currentUserID == 0 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized, user needs to be logged in",
-		})
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
 	}
 
 	var body ItemRequestPayload
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return apperr.BadRequest("Invalid request body")
 	}
 
 	if body.Name == "" || body.BoardID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Name and BoardID are required",
-		})
+		var fields []apperr.FieldError
+		if body.Name == "" {
+			fields = append(fields, apperr.Field("name", "is required"))
+		}
+		if body.BoardID == 0 {
+			fields = append(fields, apperr.Field("board_id", "is required"))
+		}
+		return apperr.Invalid("Name and BoardID are required", fields...)
 	}
 
 	// Check if board exists
 	var board models.Board
 	result := config.DB.First(&board, body.BoardID)
 	if result.Error != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Board not found",
-		})
+		return apperr.NotFound("Board not found")
+	}
+
+	if body.TemplateID != nil {
+		var template models.ItemTemplate
+		err := config.DB.Where("user_id = ? AND (board_id IS NULL OR board_id = ?)", currentUserID, board.ID).
+			First(&template, *body.TemplateID).Error
+		if err != nil {
+			return apperr.NotFound("Item template not found")
+		}
+		if err := applyItemTemplate(&body, &template); err != nil {
+			return apperr.Invalid(err.Error())
+		}
 	}
 
 	// Parse due date
@@ -54,43 +76,119 @@ func CreateProjectItem(c *fiber.Ctx) error {
 	if body.DueDate != "" {
 		parsed, err := time.Parse(time.RFC3339, body.DueDate)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid due_date format, must be RFC3339 (e.g. 2025-09-20T15:04:05Z)",
-			})
+			return apperr.Invalid("Invalid due_date format, must be RFC3339 (e.g. 2025-09-20T15:04:05Z)", apperr.Field("due_date", "must be RFC3339"))
 		}
 		dueDate = &parsed
 	}
 
+	if err := validateEstimate(&board, body.Estimate); err != nil {
+		return apperr.Invalid(err.Error(), apperr.Field("estimate", err.Error()))
+	}
+
+	// New items start in the board's first not-started status
+	status := models.ItemStatus(body.Status)
+	if status == "" {
+		initial, err := initialStatus(config.DB, board.ID)
+		if err != nil {
+			return apperr.Internal("Could not create item", err)
+		}
+		status = initial
+	}
+	if err := checkStatusChange(config.DB, board.ID, "", status); err != nil {
+		return statusChangeError(err, "Could not create item")
+	}
+
+	if body.ParentID != nil {
+		if err := validateParent(config.DB, 0, body.BoardID, *body.ParentID); err != nil {
+			return parentError(err, "Could not create item")
+		}
+	}
+
 	item := models.ProjectItem{
 		Name:        body.Name,
 		BoardID:     body.BoardID,
 		Description: body.Description,
-		Status:      models.ItemStatus(body.Status),
+		Status:      status,
 		Priority:    models.ItemPriority(body.Priority),
 		DueDate:     dueDate,
+		ParentID:    body.ParentID,
+		Estimate:    body.Estimate,
 	}
 
-	if err := config.DB.Create(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not create item: " + err.Error(),
-		})
+	var wip *wipCheck
+	var overrideWIP bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		wip, overrideWIP, err = evaluateWIP(tx, board.ID, status, currentUserID, body.WIPOverride)
+		if err != nil {
+			return wipError(wip, err, "Could not create item")
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		if overrideWIP {
+			if err := recordWIPOverride(tx, wip, board.ID, item.ID, currentUserID, body.WIPOverride); err != nil {
+				return err
+			}
+		}
+		if err := watchItem(tx, currentUserID, item.ID, models.WatchCreated); err != nil {
+			return err
+		}
+		return saveCustomFieldValues(tx, item.BoardID, item.ID, body.CustomFields, true)
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not create item", err)
+	}
+	created := []models.ProjectItem{item}
+	if err := attachCustomFields(created); err == nil {
+		item = created[0]
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+	response := fiber.Map{
 		"message": "Item created successfully",
 		"item":    item,
-	})
+	}
+	if wip != nil && !overrideWIP {
+		response["warnings"] = []string{wip.message()}
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // ✅ Get all project items
 func GetProjectItems(c *fiber.Ctx) error {
+	query, err := applyArchivedQuery(c, config.DB, "project_items")
+	if err == nil {
+		query, err = applyEstimateQuery(c, query)
+	}
+	var filter *itemFilter
+	if err == nil {
+		filter, err = parseItemFilter(c)
+	}
+	var sort *itemSort
+	if err == nil {
+		query, sort, err = itemSortOrder(c, query, 0)
+	}
+	var page *pageRequest
+	if err == nil {
+		page, err = parsePageRequest(c, sort.listOrder, "project_items.id")
+	}
+	if err != nil {
+		return queryError(err, "Could not fetch items")
+	}
+
 	var items []models.ProjectItem
-	if err := config.DB.Find(&items).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not fetch items: " + err.Error(),
-		})
+	if err := query.Scopes(filter.Scope, page.Scope).Find(&items).Error; err != nil {
+		return apperr.Internal("Could not fetch items", err)
 	}
-	return c.Status(fiber.StatusOK).JSON(items)
+	items, hasMore := trimPage(items, page.Limit)
+	if err := decorateItems(items); err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(itemPage(page, sort, items, hasMore))
 }
 
 // ✅ Get single item by ID
@@ -98,33 +196,82 @@ func GetProjectItemByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var item models.ProjectItem
 	if err := config.DB.First(&item, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Item not found",
-		})
+		return apperr.NotFound("Item not found")
+	}
+	items := []models.ProjectItem{item}
+	if err := decorateItems(items); err != nil {
+		return apperr.Internal("Could not fetch item", err)
 	}
+	item = items[0]
+	recordView(utils.GetIDFromContext(c), nil, &item.ID)
+	setETag(c, item.Version)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Item fetched successfully",
 		"item":    item,
 	})
 }
 
+// loadAccessibleItem fetches an item and checks the user can access its board.
+// On failure it returns a nil item and the error to respond with.
+func loadAccessibleItem(userID, itemID uint) (*models.ProjectItem, error) {
+	var item models.ProjectItem
+	if err := config.DB.First(&item, itemID).Error; err != nil {
+		return nil, apperr.NotFound("Item not found")
+	}
+	if !canAccessBoard(userID, item.BoardID) {
+		return nil, apperr.Forbidden("You do not have access to this board")
+	}
+	return &item, nil
+}
+
+// loadItemParam is loadAccessibleItem for the item in the :id param, for the
+// logged-in caller. On failure it returns nil and the error to respond with.
+func loadItemParam(c *fiber.Ctx) (*models.ProjectItem, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return nil, apperr.Invalid("Invalid ID format")
+	}
+	return loadAccessibleItem(currentUserID, uint(id))
+}
+
+// staleItem turns a version conflict into a 412 with the item as it is now.
+// Other errors pass through.
+func staleItem(c *fiber.Ctx, id uint, err error) error {
+	if !errors.Is(err, errVersionConflict) {
+		return err
+	}
+	var current models.ProjectItem
+	if err := config.DB.First(&current, id).Error; err != nil {
+		return apperr.NotFound("Item not found")
+	}
+	items := []models.ProjectItem{current}
+	if err := decorateItems(items); err != nil {
+		return apperr.Internal("Could not fetch item", err)
+	}
+	return staleWrite(c, current.Version, items[0])
+}
+
 // ✅ Update item
 func UpdateProjectItem(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var item models.ProjectItem
-	if err := config.DB.First(&item, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Item not found",
-		})
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+	if err := checkIfMatch(c, item.Version); err != nil {
+		return staleItem(c, item.ID, err)
 	}
 
 	var body ItemRequestPayload
 	if err := c.BodyParser(&body); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return apperr.BadRequest("Invalid request body")
 	}
 
+	previous := *item
+
 	// Update fields
 	item.Name = body.Name
 	item.Description = body.Description
@@ -134,43 +281,350 @@ func UpdateProjectItem(c *fiber.Ctx) error {
 	if body.DueDate != "" {
 		parsed, err := time.Parse(time.RFC3339, body.DueDate)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid due_date format",
-			})
+			return apperr.Invalid("Invalid due_date format", apperr.Field("due_date", "must be RFC3339"))
 		}
 		item.DueDate = &parsed
 	}
+	// A PUT without parent_id keeps the item where it is; null un-nests it
+	if bodyHasField(c, "parent_id") {
+		item.ParentID = body.ParentID
+	}
+	// Likewise a PUT without estimate keeps it; null clears it
+	if bodyHasField(c, "estimate") {
+		item.Estimate = body.Estimate
+	}
+
+	return saveItemUpdate(c, item, &previous, body.CustomFields, body.WIPOverride, nil)
+}
+
+// bodyHasField reports whether the JSON body sends key at all, null included.
+func bodyHasField(c *fiber.Ctx, key string) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &fields); err != nil {
+		return false
+	}
+	_, sent := fields[key]
+	return sent
+}
 
-	if err := config.DB.Save(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not update item: " + err.Error(),
-		})
+// saveItemUpdate checks an item whose fields were just changed against its
+// board (parent, estimate, workflow, WIP limits, blockers) and writes it,
+// along with any custom field values. previous is the item as it was loaded.
+// With columns nil the whole row is saved; otherwise only those columns are,
+// and nothing when columns is empty.
+func saveItemUpdate(c *fiber.Ctx, item, previous *models.ProjectItem, customFields map[string]interface{}, wipReason string, columns []string) error {
+	if item.ParentID != nil {
+		if err := validateParent(config.DB, item.ID, item.BoardID, *item.ParentID); err != nil {
+			return parentError(err, "Could not update item")
+		}
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	var board models.Board
+	if err := config.DB.First(&board, item.BoardID).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if err := validateEstimate(&board, item.Estimate); err != nil {
+		return apperr.Invalid(err.Error(), apperr.Field("estimate", err.Error()))
+	}
+
+	if err := checkStatusChange(config.DB, item.BoardID, previous.Status, item.Status); err != nil {
+		return statusChangeError(err, "Could not update item")
+	}
+
+	wasComplete, err := isCompleteStatus(config.DB, item.BoardID, previous.Status)
+	if err != nil {
+		return apperr.Internal("Could not update item", err)
+	}
+	isComplete, err := isCompleteStatus(config.DB, item.BoardID, item.Status)
+	if err != nil {
+		return apperr.Internal("Could not update item", err)
+	}
+
+	// Only finishing an item is refused; a done item can still be edited
+	if board.EnforceBlockers && !wasComplete && isComplete {
+		blockers, err := openBlockers(config.DB, item.ID)
+		if err != nil {
+			return apperr.Internal("Could not update item", err)
+		}
+		if len(blockers) > 0 {
+			return apperr.Conflict("Item is blocked by unfinished items").WithCode(codeItemBlocked).With("blocked_by", blockers)
+		}
+	}
+
+	var next *models.ProjectItem
+	var wip *wipCheck
+	var overrideWIP bool
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Only a status change adds load to a column
+		if item.Status != previous.Status {
+			var err error
+			wip, overrideWIP, err = evaluateWIP(tx, item.BoardID, item.Status, utils.GetIDFromContext(c), wipReason)
+			if err != nil {
+				return wipError(wip, err, "Could not update item")
+			}
+		}
+		if columns == nil {
+			if err := tx.Save(item).Error; err != nil {
+				return err
+			}
+		} else if len(columns) > 0 {
+			if err := tx.Model(item).Select(columns).Updates(item).Error; err != nil {
+				return err
+			}
+		} else if len(customFields) > 0 {
+			// Custom fields are part of the item, so changing only them is a new version too
+			if err := claimVersion(tx, &models.ProjectItem{}, item.ID, item.Version); err != nil {
+				return err
+			}
+			item.Version++
+		}
+		if overrideWIP {
+			if err := recordWIPOverride(tx, wip, item.BoardID, item.ID, utils.GetIDFromContext(c), wipReason); err != nil {
+				return err
+			}
+		}
+		if err := saveCustomFieldValues(tx, item.BoardID, item.ID, customFields, false); err != nil {
+			return err
+		}
+
+		// Finishing a recurring item generates its next occurrence
+		if !wasComplete && isComplete {
+			var err error
+			next, err = completeOccurrence(tx, item)
+			return err
+		}
+		return nil
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if errors.Is(err, errVersionConflict) {
+		return staleItem(c, item.ID, err)
+	}
+	if err != nil {
+		return apperr.Internal("Could not update item", err)
+	}
+
+	setETag(c, item.Version)
+	response := fiber.Map{
 		"message": "Item updated successfully",
 		"item":    item,
-	})
+	}
+	if wip != nil && !overrideWIP {
+		response["warnings"] = []string{wip.message()}
+	}
+	if next != nil {
+		response["next_occurrence"] = next
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// itemPatchFields are the keys an item PATCH may send. Moving an item to
+// another board goes through /move instead.
+var itemPatchFields = []string{"name", "description", "due_date", "status", "priority", "parent_id", "estimate", "custom_fields", "wip_override_reason"}
+
+// checkItemFields validates an item's own fields as a whole, as they will be
+// stored.
+func checkItemFields(body *ItemRequestPayload) error {
+	var fields []apperr.FieldError
+	if body.Name == "" {
+		fields = append(fields, apperr.Field("name", "is required"))
+	} else if utf8.RuneCountInString(body.Name) > 50 {
+		fields = append(fields, apperr.Field("name", "must be at most 50 characters long"))
+	}
+	if utf8.RuneCountInString(body.Description) > 255 {
+		fields = append(fields, apperr.Field("description", "must be at most 255 characters long"))
+	}
+	if body.Status == "" {
+		fields = append(fields, apperr.Field("status", "is required"))
+	}
+	switch models.ItemPriority(body.Priority) {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+	default:
+		fields = append(fields, apperr.Field("priority", "must be low, medium or high"))
+	}
+	if body.DueDate != "" {
+		if _, err := time.Parse(time.RFC3339, body.DueDate); err != nil {
+			fields = append(fields, apperr.Field("due_date", "must be RFC3339"))
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.Invalid("Invalid item", fields...)
+	}
+	return nil
+}
+
+// patchedCustomFields turns the custom_fields member of an item patch into the
+// values to save. Its keys already merge one by one; null clears every field.
+func patchedCustomFields(boardID uint, value interface{}, present bool) (map[string]interface{}, error) {
+	if !present {
+		return nil, nil
+	}
+	if value == nil {
+		var keys []string
+		if err := config.DB.Model(&models.CustomField{}).Where("board_id = ?", boardID).Pluck("field_key", &keys).Error; err != nil {
+			return nil, apperr.Internal("Could not update item", err)
+		}
+		cleared := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			cleared[key] = nil
+		}
+		return cleared, nil
+	}
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, apperr.Invalid("custom_fields must be an object", apperr.Field("custom_fields", "must be an object"))
+	}
+	return values, nil
+}
+
+// ✅ Patch item: a JSON Merge Patch, so fields left out keep their value,
+// null clears optional ones, and only the columns that change are written
+func PatchProjectItem(c *fiber.Ctx) error {
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+	if err := checkIfMatch(c, item.Version); err != nil {
+		return staleItem(c, item.ID, err)
+	}
+
+	patch, err := parseMergePatch(c, itemPatchFields...)
+	if err != nil {
+		return err
+	}
+
+	// custom_fields and wip_override_reason aren't columns of the item
+	rawFields, present := patch["custom_fields"]
+	customFields, err := patchedCustomFields(item.BoardID, rawFields, present)
+	if err != nil {
+		return err
+	}
+	wipReason, ok := patch["wip_override_reason"].(string)
+	if !ok && patch["wip_override_reason"] != nil {
+		return apperr.Invalid("wip_override_reason must be a string", apperr.Field("wip_override_reason", "must be a string"))
+	}
+	delete(patch, "custom_fields")
+	delete(patch, "wip_override_reason")
+
+	current := ItemRequestPayload{
+		Name:        item.Name,
+		Description: item.Description,
+		Status:      string(item.Status),
+		Priority:    string(item.Priority),
+		BoardID:     item.BoardID,
+		ParentID:    item.ParentID,
+		Estimate:    item.Estimate,
+	}
+	if item.DueDate != nil {
+		current.DueDate = item.DueDate.Format(time.RFC3339)
+	}
+	merged, err := mergeInto(current, patch)
+	if err != nil {
+		return err
+	}
+	if err := checkItemFields(&merged); err != nil {
+		return err
+	}
+
+	previous := *item
+	var columns []string
+	if merged.Name != item.Name {
+		item.Name = merged.Name
+		columns = append(columns, "name")
+	}
+	if merged.Description != item.Description {
+		item.Description = merged.Description
+		columns = append(columns, "description")
+	}
+	if status := models.ItemStatus(merged.Status); status != item.Status {
+		item.Status = status
+		columns = append(columns, "status")
+	}
+	if priority := models.ItemPriority(merged.Priority); priority != item.Priority {
+		item.Priority = priority
+		columns = append(columns, "priority")
+	}
+	// The current due date went through RFC3339 above, which drops fractions
+	// of a second, so only a due_date in the patch may change it
+	if _, ok := patch["due_date"]; ok {
+		var dueDate *time.Time
+		if merged.DueDate != "" {
+			parsed, _ := time.Parse(time.RFC3339, merged.DueDate)
+			dueDate = &parsed
+		}
+		if !sameTime(dueDate, item.DueDate) {
+			item.DueDate = dueDate
+			columns = append(columns, "due_date")
+		}
+	}
+	if !samePointer(merged.ParentID, item.ParentID) {
+		item.ParentID = merged.ParentID
+		columns = append(columns, "parent_id")
+	}
+	if !samePointer(merged.Estimate, item.Estimate) {
+		item.Estimate = merged.Estimate
+		columns = append(columns, "estimate")
+	}
+	if columns == nil {
+		columns = []string{} // nothing to write but custom fields
+	}
+
+	return saveItemUpdate(c, item, &previous, customFields, wipReason, columns)
 }
 
 func DeleteProjectItem(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var item models.ProjectItem
-	if err := config.DB.First(&item, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Item not found",
-		})
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+	if err := checkIfMatch(c, item.Version); err != nil {
+		return staleItem(c, item.ID, err)
 	}
 
-	if err := config.DB.Delete(&item).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not delete item: " + err.Error(),
-		})
+	// Subtasks need an explicit policy: ?children=cascade or ?children=orphan
+	var childCount int64
+	if err := config.DB.Model(&models.ProjectItem{}).Where("parent_id = ?", item.ID).Count(&childCount).Error; err != nil {
+		return apperr.Internal("Could not delete item", err)
 	}
 
-	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
-		"message": "Item deleted successfully",
+	policy := models.ChildDeletePolicy(c.Query("children"))
+	if childCount > 0 && policy != models.ChildDeleteCascade && policy != models.ChildDeleteOrphan {
+		return apperr.Conflict("Item has subtasks, choose a delete policy with ?children=cascade or ?children=orphan").
+			WithCode(codeItemHasChildren).With("children", childCount)
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimVersion(tx, &models.ProjectItem{}, item.ID, item.Version); err != nil {
+			return err
+		}
+		if childCount > 0 {
+			switch policy {
+			case models.ChildDeleteCascade:
+				ids, err := descendantIDs(tx, item.ID)
+				if err != nil {
+					return err
+				}
+				// One batch, so the subtree restores together
+				return trashItems(tx, append(ids, item.ID))
+			case models.ChildDeleteOrphan:
+				if err := tx.Model(&models.ProjectItem{}).Where("parent_id = ?", item.ID).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return trashItems(tx, []uint{item.ID})
 	})
+	if errors.Is(err, errVersionConflict) {
+		return staleItem(c, item.ID, err)
+	}
+	if err != nil {
+		return apperr.Internal("Could not delete item", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // ✅ Get items by board ID
@@ -178,14 +632,39 @@ func GetProjectItemsByBoardID(c *fiber.Ctx) error {
 	boardID := c.Params("id")
 	var items []models.ProjectItem
 
-	if err := config.DB.Where("board_id = ?", boardID).Find(&items).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not fetch items: " + err.Error(),
-		})
+	query, err := applyArchivedQuery(c, config.DB.Where("project_items.board_id = ?", boardID), "project_items")
+	if err == nil {
+		query, err = applyEstimateQuery(c, query)
 	}
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Items fetched successfully",
-		"items":   items,
-	})
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	id, _ := strconv.ParseUint(boardID, 10, 64)
+	query, err = applyCustomFieldQuery(c, query, uint(id))
+	var filter *itemFilter
+	if err == nil {
+		filter, err = parseItemFilter(c)
+	}
+	var sort *itemSort
+	if err == nil {
+		query, sort, err = itemSortOrder(c, query, uint(id))
+	}
+	var page *pageRequest
+	if err == nil {
+		page, err = parsePageRequest(c, sort.listOrder, "project_items.id")
+	}
+	if err != nil {
+		return queryError(err, "Could not fetch items")
+	}
+
+	if err := query.Scopes(filter.Scope, page.Scope).Find(&items).Error; err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	items, hasMore := trimPage(items, page.Limit)
+	if err := decorateItems(items); err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(itemPage(page, sort, items, hasMore))
 
 }
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"database/sql/driver"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// A PUT only moves an item to another parent when parent_id is in the body.
+func TestBodyHasField(t *testing.T) {
+	tests := map[string]bool{
+		`{"name":"Docs","parent_id":4}`:    true,
+		`{"name":"Docs","parent_id":null}`: true,
+		`{"name":"Docs"}`:                  false,
+		`{"name":"parent_id"}`:             false,
+		`not json`:                         false,
+	}
+	for body, want := range tests {
+		var got bool
+		app := fiber.New()
+		app.Put("/", func(c *fiber.Ctx) error {
+			got = bodyHasField(c, "parent_id")
+			return nil
+		})
+		if _, err := app.Test(httptest.NewRequest("PUT", "/", strings.NewReader(body))); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("bodyHasField(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+// A PUT that leaves estimate out keeps it, like parent_id.
+func TestUpdateProjectItemKeepsEstimate(t *testing.T) {
+	saved := config.DB
+	defer func() { config.DB = saved }()
+
+	put := func(body string) string {
+		t.Helper()
+		db, fake := openFakeDB(t)
+		config.DB = db
+		fake.answer([]string{"id", "board_id", "name", "status", "priority", "estimate", "version"},
+			[]driver.Value{int64(5), int64(2), "Docs", "doing", "low", 2.0, int64(1)})
+		fake.answer([]string{"count"}, []driver.Value{int64(1)}) // board access
+		fake.answer([]string{"id", "estimate_unit"}, []driver.Value{int64(2), "points"})
+		fake.answer([]string{"id", "board_id", "status_key", "category"},
+			[]driver.Value{int64(1), int64(2), "doing", string(models.CategoryActive)})
+
+		app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+		app.Put("/:id", func(c *fiber.Ctx) error {
+			c.Locals("user_id", uint(7))
+			return UpdateProjectItem(c)
+		})
+		req := httptest.NewRequest("PUT", "/5", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("PUT %s: status %d", body, resp.StatusCode)
+		}
+		for _, statement := range fake.sent() {
+			if strings.HasPrefix(statement, "UPDATE `project_items`") {
+				return statement
+			}
+		}
+		t.Fatalf("PUT %s wrote nothing", body)
+		return ""
+	}
+
+	if update := put(`{"name":"Docs","status":"doing","priority":"low"}`); !strings.Contains(update, "`estimate`=2") {
+		t.Errorf("estimate left out: %s", update)
+	}
+	if update := put(`{"name":"Docs","status":"doing","priority":"low","estimate":null}`); !strings.Contains(update, "`estimate`=<nil>") {
+		t.Errorf("estimate null: %s", update)
+	}
+}
+
+// custom_fields: null in a patch clears every field of the board.
+func TestPatchedCustomFieldsNull(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	fake.answer([]string{"field_key"}, []driver.Value{"team"}, []driver.Value{"size"})
+	values, err := patchedCustomFields(3, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"team": nil, "size": nil}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if sent := fake.sent(); len(sent) != 1 || !strings.HasPrefix(sent[0], "SELECT `field_key` FROM `custom_fields`") {
+		t.Errorf("lookup = %q", sent)
+	}
+
+	if values, err := patchedCustomFields(3, nil, false); values != nil || err != nil {
+		t.Errorf("left out: %v, %v", values, err)
+	}
+	if _, err := patchedCustomFields(3, "x", true); err == nil {
+		t.Error("a string was accepted as custom_fields")
+	}
+}
+
+// A board that enforces blockers refuses to finish a blocked item, but an item
+// that is already done can still be edited.
+func TestSaveItemUpdateBlockers(t *testing.T) {
+	saved := config.DB
+	defer func() { config.DB = saved }()
+
+	update := func(from models.ItemStatus) (int, []string) {
+		t.Helper()
+		db, fake := openFakeDB(t)
+		config.DB = db
+		fake.answer([]string{"id", "enforce_blockers", "estimate_unit"}, []driver.Value{int64(2), true, "points"})
+		fake.answer([]string{"id", "board_id", "status_key", "category"},
+			[]driver.Value{int64(1), int64(2), "doing", string(models.CategoryActive)},
+			[]driver.Value{int64(2), int64(2), "done", string(models.CategoryComplete)})
+		fake.answer([]string{"id"}) // no transitions
+		fake.answer([]string{"count"}, []driver.Value{boolCount(from == "done")})
+		fake.answer([]string{"count"}, []driver.Value{int64(1)}) // done is complete
+		if from != "done" {
+			fake.answer([]string{"blocker_id"}, []driver.Value{int64(8)}) // item 8 is still open
+		}
+		fake.answer([]string{"id", "board_id", "field_key"}) // no custom fields
+
+		item := &models.ProjectItem{BoardID: 2, Status: "done", Priority: models.PriorityHigh}
+		item.ID = 5
+		previous := *item
+		previous.Status, previous.Priority = from, models.PriorityLow
+
+		app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+		app.Put("/", func(c *fiber.Ctx) error {
+			return saveItemUpdate(c, item, &previous, nil, "", []string{"status", "priority"})
+		})
+		resp, err := app.Test(httptest.NewRequest("PUT", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, fake.sent()
+	}
+
+	if status, _ := update("doing"); status != fiber.StatusConflict {
+		t.Errorf("finishing a blocked item: status %d, want 409", status)
+	}
+	status, sent := update("done")
+	if status != fiber.StatusOK {
+		t.Errorf("editing a done item with an open blocker: status %d, want 200", status)
+	}
+	for _, statement := range sent {
+		if strings.Contains(statement, "item_dependencies") {
+			t.Errorf("looked up blockers for an item that stays done: %s", statement)
+		}
+	}
+}
+
+// Changing only custom fields is a new version of the item, with a new ETag.
+func TestSaveItemUpdateCustomFieldsOnly(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	fake.answer([]string{"id", "estimate_unit"}, []driver.Value{int64(2), "points"})
+	fake.answer([]string{"id", "board_id", "status_key", "category"},
+		[]driver.Value{int64(1), int64(2), "doing", string(models.CategoryActive)})
+	fake.answer([]string{"id"}) // no transitions
+	fake.answer([]string{"count"}, []driver.Value{int64(0)})
+	fake.answer([]string{"count"}, []driver.Value{int64(0)})
+	fake.answer([]string{"id", "board_id", "field_key", "type"}, []driver.Value{int64(9), int64(2), "points", string(models.FieldNumber)})
+
+	item := &models.ProjectItem{BoardID: 2, Status: "doing", Priority: models.PriorityLow, Version: 3}
+	item.ID = 5
+	previous := *item
+
+	app := fiber.New(fiber.Config{ErrorHandler: apperr.Handler})
+	app.Patch("/", func(c *fiber.Ctx) error {
+		return saveItemUpdate(c, item, &previous, map[string]interface{}{"points": float64(3)}, "", []string{})
+	})
+	resp, err := app.Test(httptest.NewRequest("PATCH", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"4"` {
+		t.Errorf("status %d, ETag %s; want 200 and version 4", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+	var claimed bool
+	for _, statement := range fake.sent() {
+		claimed = claimed || strings.Contains(statement, "`version`=version + 1") && strings.Contains(statement, "version = 3")
+	}
+	if !claimed {
+		t.Error("the item's version was not bumped")
+	}
+}
+
+func boolCount(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Lengths are counted in characters, as the columns are sized, not in bytes.
+func TestCheckItemFieldsLength(t *testing.T) {
+	item := ItemRequestPayload{Name: strings.Repeat("é", 50), Description: strings.Repeat("日", 255), Status: "todo", Priority: "low"}
+	if err := checkItemFields(&item); err != nil {
+		t.Errorf("a 50 character name: %v", err)
+	}
+	item.Name += "é"
+	if err := checkItemFields(&item); err == nil {
+		t.Error("a 51 character name was accepted")
+	}
+
+	board := BoardRequest{Name: strings.Repeat("ü", 100), Description: strings.Repeat("ü", 255)}
+	if err := checkBoardRequest(&board); err != nil {
+		t.Errorf("a 100 character title: %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type savedViewRequest struct {
+	Name   string `json:"name"`
+	Query  string `json:"query"` // item query language, see item_query_language.go
+	Sort   string `json:"sort"`  // as in ?sort= on item listings
+	Shared *bool  `json:"shared"`
+}
+
+type defaultViewRequest struct {
+	ViewID *uint `json:"view_id"` // null clears the default
+}
+
+// validateSavedView checks that the view's name, query and sort are usable.
+func validateSavedView(view *models.SavedView) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" || utf8.RuneCountInString(view.Name) > 100 {
+		return errors.New("name is required and must be at most 100 characters")
+	}
+	if view.Query != "" {
+		if _, err := compileItemQuery(config.DB, view.UserID, view.Query, time.Now()); err != nil {
+			return err
+		}
+	}
+	_, _, err := itemSortBy(view.Sort, config.DB, view.BoardID)
+	return err
+}
+
+// loadSavedView fetches the view in the :id param if the caller can see it:
+// their own views, and shared views on boards they can access. On failure it
+// returns nil and the error to respond with.
+func loadSavedView(c *fiber.Ctx, userID uint) (*models.SavedView, error) {
+	var view models.SavedView
+	if err := config.DB.First(&view, c.Params("id")).Error; err != nil ||
+		(view.UserID != userID && !view.Shared) || !canAccessBoard(userID, view.BoardID) {
+		return nil, apperr.NotFound("View not found")
+	}
+	return &view, nil
+}
+
+// loadOwnSavedView is loadSavedView for changes, which only the owner may make.
+func loadOwnSavedView(c *fiber.Ctx) (*models.SavedView, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+	view, err := loadSavedView(c, currentUserID)
+	if view == nil {
+		return nil, err
+	}
+	if view.UserID != currentUserID {
+		return nil, apperr.Forbidden("Only the owner can change this view")
+	}
+	return view, nil
+}
+
+// runSavedView responds with one page of the items the view selects.
+func runSavedView(c *fiber.Ctx, view *models.SavedView) error {
+	filter := &itemFilter{}
+	query, err := applyArchivedQuery(c, config.DB.Where("project_items.board_id = ?", view.BoardID), "project_items")
+	if err == nil && view.Query != "" {
+		filter.Query, err = compileItemQuery(config.DB, utils.GetIDFromContext(c), view.Query, time.Now())
+	}
+	var sort *itemSort
+	if err == nil {
+		query, sort, err = itemSortBy(view.Sort, query, view.BoardID)
+	}
+	var page *pageRequest
+	if err == nil {
+		page, err = parsePageRequest(c, sort.listOrder, "project_items.id")
+	}
+	if err != nil {
+		return queryError(err, "Could not fetch items")
+	}
+
+	var items []models.ProjectItem
+	if err := query.Scopes(filter.Scope, page.Scope).Find(&items).Error; err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	items, hasMore := trimPage(items, page.Limit)
+	if err := decorateItems(items); err != nil {
+		return apperr.Internal("Could not fetch items", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(itemPage(page, sort, items, hasMore))
+}
+
+// ✅ Save a named view on a board
+func CreateSavedView(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	var body savedViewRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+
+	view := models.SavedView{
+		BoardID: board.ID,
+		UserID:  currentUserID,
+		Name:    body.Name,
+		Query:   body.Query,
+		Sort:    body.Sort,
+		Shared:  body.Shared != nil && *body.Shared,
+	}
+	if err := validateSavedView(&view); err != nil {
+		return queryError(err, "Could not save view")
+	}
+
+	if err := config.DB.Create(&view).Error; err != nil {
+		return apperr.Internal("Could not save view", err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+// ✅ List the views on a board the caller can see: their own and shared ones
+func GetBoardSavedViews(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	page, err := parsePageRequest(c, listOrder{}, "saved_views.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	var views []models.SavedView
+	err = config.DB.Where("board_id = ? AND (user_id = ? OR shared = ?)", board.ID, currentUserID, true).
+		Scopes(page.Scope).
+		Find(&views).Error
+	if err != nil {
+		return apperr.Internal("Could not fetch views", err)
+	}
+	views, hasMore := trimPage(views, page.Limit)
+
+	var lastID uint
+	if len(views) > 0 {
+		lastID = views[len(views)-1].ID
+	}
+	return c.JSON(page.result(views, hasMore, nil, lastID))
+}
+
+// ✅ Get a saved view
+func GetSavedView(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+	view, err := loadSavedView(c, currentUserID)
+	if view == nil {
+		return err
+	}
+	return c.JSON(view)
+}
+
+// ✅ Update a saved view (owner only)
+func UpdateSavedView(c *fiber.Ctx) error {
+	view, err := loadOwnSavedView(c)
+	if view == nil {
+		return err
+	}
+
+	var body savedViewRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+
+	view.Name, view.Query, view.Sort = body.Name, body.Query, body.Sort
+	if body.Shared != nil {
+		view.Shared = *body.Shared
+	}
+	if err := validateSavedView(view); err != nil {
+		return queryError(err, "Could not update view")
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if !view.Shared {
+			// A board only opens with a view everyone on it can see
+			if err := tx.Model(&models.Board{}).Where("default_view_id = ?", view.ID).Update("default_view_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(view).Error
+	})
+	if err != nil {
+		return apperr.Internal("Could not update view", err)
+	}
+	return c.JSON(view)
+}
+
+// ✅ Delete a saved view (owner only)
+func DeleteSavedView(c *fiber.Ctx) error {
+	view, err := loadOwnSavedView(c)
+	if view == nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Board{}).Where("default_view_id = ?", view.ID).Update("default_view_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(view).Error
+	})
+	if err != nil {
+		return apperr.Internal("Could not delete view", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Run a saved view: one page of the items it selects, in its order
+func RunSavedView(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+	view, err := loadSavedView(c, currentUserID)
+	if view == nil {
+		return err
+	}
+	return runSavedView(c, view)
+}
+
+// ✅ Set or clear the view a board opens with (board admins only)
+func SetBoardDefaultView(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var body defaultViewRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	// The view is locked so it can't stop being shared before it is the default
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if body.ViewID != nil {
+			var view models.SavedView
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("board_id = ?", board.ID).First(&view, *body.ViewID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperr.Invalid("View not found on this board")
+			}
+			if err != nil {
+				return err
+			}
+			if !view.Shared {
+				return apperr.Invalid("Only a shared view can be the board's default")
+			}
+		}
+		return tx.Model(board).Update("default_view_id", body.ViewID).Error
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not set default view", err)
+	}
+	board.DefaultViewID = body.ViewID
+	return c.JSON(board)
+}
+
+// ✅ Run a board's default view
+func RunBoardDefaultView(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	var view models.SavedView
+	if board.DefaultViewID == nil || config.DB.First(&view, *board.DefaultViewID).Error != nil {
+		return apperr.NotFound("Board has no default view")
+	}
+	return runSavedView(c, &view)
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestValidateSavedViewName(t *testing.T) {
+	tests := map[string]bool{
+		"Overdue":                true,
+		"  ":                     false,
+		strings.Repeat("ß", 100): true,
+		strings.Repeat("ß", 101): false,
+	}
+	for name, ok := range tests {
+		view := models.SavedView{Name: name}
+		if err := validateSavedView(&view); (err == nil) != ok {
+			t.Errorf("name of %d characters: err = %v", len([]rune(name)), err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Results returned when the request has no limit, and the most it may ask for
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var activeSearchIndex searchIndex
+
+// InitSearchIndex picks the search backend. SEARCH_INDEX is mysql (FULLTEXT)
+// or memory (an in-process inverted index); it defaults to mysql on MySQL and
+// memory on anything else. Call it after the tables are migrated.
+func InitSearchIndex() error {
+	fallback := "memory"
+	if config.DB.Dialector.Name() == "mysql" {
+		fallback = "mysql"
+	}
+	backend := utils.GetEnv("SEARCH_INDEX", fallback)
+	switch backend {
+	case "mysql":
+		activeSearchIndex = &mysqlSearchIndex{db: config.DB}
+	case "memory":
+		memory := newMemorySearchIndex(config.DB)
+		if err := watchSearchWrites(config.DB, memory); err != nil {
+			return err
+		}
+		activeSearchIndex = memory
+	default:
+		return fmt.Errorf("unknown SEARCH_INDEX %q, use mysql or memory", backend)
+	}
+	return nil
+}
+
+// ✅ Search boards and items the user can access by name and description
+func Search(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || len(query) > 100 {
+		return apperr.Invalid("q is required and must be at most 100 characters", apperr.Field("q", "is required and must be at most 100 characters"))
+	}
+
+	limit := defaultSearchLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return apperr.Invalid("limit must be a positive number", apperr.Field("limit", "must be a positive number"))
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	boardIDs, err := accessibleBoardIDs(currentUserID)
+	if err != nil {
+		return apperr.Internal("Could not search", err)
+	}
+
+	hits, err := activeSearchIndex.Search(query, boardIDs, limit)
+	if err != nil {
+		return apperr.Internal("Could not search", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"query":   query,
+		"results": hits,
+	})
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+)
+
+// searchHit is one ranked search result.
+type searchHit struct {
+	Kind    string  `json:"kind"` // board or item
+	ID      uint    `json:"id"`
+	BoardID uint    `json:"board_id"`
+	Name    string  `json:"name"`
+	Snippet string  `json:"snippet,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// searchIndex finds boards and items by the words in their names and
+// descriptions. Items have no comments yet; an index would add them here.
+type searchIndex interface {
+	// Search returns the best limit matches for query among the given boards
+	// and their items, highest score first.
+	Search(query string, boardIDs []uint, limit int) ([]searchHit, error)
+}
+
+const snippetLength = 160
+
+// snippet shortens a description to snippetLength characters.
+func snippet(description string) string {
+	if utf8.RuneCountInString(description) <= snippetLength {
+		return description
+	}
+	return string([]rune(description)[:snippetLength]) + "…"
+}
+
+// rankHits sorts hits by score, highest first, and keeps the best limit.
+func rankHits(hits []searchHit, limit int) []searchHit {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// mysqlSearchIndex uses the FULLTEXT indexes on boards and project_items.
+type mysqlSearchIndex struct {
+	db *gorm.DB
+}
+
+func (s *mysqlSearchIndex) Search(query string, boardIDs []uint, limit int) ([]searchHit, error) {
+	if len(boardIDs) == 0 {
+		return []searchHit{}, nil
+	}
+
+	type row struct {
+		ID          uint
+		BoardID     uint
+		Name        string
+		Description string
+		Score       float64
+	}
+	const match = "MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	var boards []row
+	err := s.db.Model(&models.Board{}).
+		Select("id, id AS board_id, name, description, "+match+" AS score", query).
+		Where("id IN ?", boardIDs).
+		Where(match, query).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&boards).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var items []row
+	err = s.db.Model(&models.ProjectItem{}).
+		Select("id, board_id, name, description, "+match+" AS score", query).
+		Where("board_id IN ?", boardIDs).
+		Where(match, query).
+		Order("score DESC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]searchHit, 0, len(boards)+len(items))
+	for _, b := range boards {
+		hits = append(hits, searchHit{Kind: "board", ID: b.ID, BoardID: b.BoardID, Name: b.Name, Snippet: snippet(b.Description), Score: b.Score})
+	}
+	for _, i := range items {
+		hits = append(hits, searchHit{Kind: "item", ID: i.ID, BoardID: i.BoardID, Name: i.Name, Snippet: snippet(i.Description), Score: i.Score})
+	}
+	return rankHits(hits, limit), nil
+}
+
+// memorySearchIndex is an inverted index kept in memory, for databases
+// without FULLTEXT support. Committed writes to boards and items only mark it
+// stale (see watchSearchWrites); it reloads what changed on the next search.
+type memorySearchIndex struct {
+	db *gorm.DB
+
+	mu       sync.Mutex
+	docs     map[searchDocKey]*searchDoc
+	postings map[string]map[searchDocKey]int // term -> doc -> weighted term frequency
+	dirty    map[searchDocKey]bool
+	rebuild  bool
+}
+
+type searchDocKey struct {
+	Kind string
+	ID   uint
+}
+
+type searchDoc struct {
+	hit   searchHit
+	terms map[string]int
+}
+
+func newMemorySearchIndex(db *gorm.DB) *memorySearchIndex {
+	return &memorySearchIndex{db: db, rebuild: true}
+}
+
+// tokenize splits text into lowercase words of two or more letters or digits.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) >= 2 {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// markDirty schedules one document, or the whole index when id is 0, for reloading.
+func (s *memorySearchIndex) markDirty(kind string, id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == 0 {
+		s.rebuild = true
+		return
+	}
+	if s.dirty == nil {
+		s.dirty = map[searchDocKey]bool{}
+	}
+	s.dirty[searchDocKey{kind, id}] = true
+}
+
+func (s *memorySearchIndex) put(key searchDocKey, hit searchHit, name, description string) {
+	s.remove(key)
+	terms := map[string]int{}
+	for _, t := range tokenize(name) {
+		terms[t] += 2 // a word in the name counts double
+	}
+	for _, t := range tokenize(description) {
+		terms[t]++
+	}
+	hit.Kind, hit.ID, hit.Snippet = key.Kind, key.ID, snippet(description)
+	s.docs[key] = &searchDoc{hit: hit, terms: terms}
+	for t, n := range terms {
+		if s.postings[t] == nil {
+			s.postings[t] = map[searchDocKey]int{}
+		}
+		s.postings[t][key] = n
+	}
+}
+
+func (s *memorySearchIndex) remove(key searchDocKey) {
+	doc, ok := s.docs[key]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		delete(s.postings[t], key)
+		if len(s.postings[t]) == 0 {
+			delete(s.postings, t)
+		}
+	}
+	delete(s.docs, key)
+}
+
+// refresh brings the index up to date with the database. Callers hold s.mu.
+func (s *memorySearchIndex) refresh() error {
+	var boardIDs, itemIDs []uint
+	if s.rebuild {
+		s.docs = map[searchDocKey]*searchDoc{}
+		s.postings = map[string]map[searchDocKey]int{}
+	} else {
+		for key := range s.dirty {
+			s.remove(key)
+			if key.Kind == "board" {
+				boardIDs = append(boardIDs, key.ID)
+			} else {
+				itemIDs = append(itemIDs, key.ID)
+			}
+		}
+		if len(boardIDs) == 0 && len(itemIDs) == 0 {
+			return nil
+		}
+	}
+
+	var boards []models.Board
+	query := s.db.Session(&gorm.Session{NewDB: true})
+	if !s.rebuild {
+		query = query.Where("id IN ?", append(boardIDs, 0))
+	}
+	if err := query.Find(&boards).Error; err != nil {
+		return err
+	}
+	var items []models.ProjectItem
+	query = s.db.Session(&gorm.Session{NewDB: true})
+	if !s.rebuild {
+		query = query.Where("id IN ?", append(itemIDs, 0))
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, b := range boards {
+		s.put(searchDocKey{"board", b.ID}, searchHit{BoardID: b.ID, Name: b.Name}, b.Name, b.Description)
+	}
+	for _, i := range items {
+		s.put(searchDocKey{"item", i.ID}, searchHit{BoardID: i.BoardID, Name: i.Name}, i.Name, i.Description)
+	}
+	s.rebuild = false
+	s.dirty = nil
+	return nil
+}
+
+// Search scores documents by TF-IDF over the query words. The last word also
+// matches as a prefix, so results show up while the user is still typing.
+func (s *memorySearchIndex) Search(query string, boardIDs []uint, limit int) ([]searchHit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[uint]bool, len(boardIDs))
+	for _, id := range boardIDs {
+		allowed[id] = true
+	}
+
+	words := tokenize(query)
+	scores := map[searchDocKey]float64{}
+	total := float64(len(s.docs))
+	for i, word := range words {
+		terms := []string{word}
+		if i == len(words)-1 {
+			terms = terms[:0]
+			for t := range s.postings {
+				if strings.HasPrefix(t, word) {
+					terms = append(terms, t)
+				}
+			}
+		}
+		for _, t := range terms {
+			postings := s.postings[t]
+			idf := math.Log(1 + total/float64(len(postings)))
+			for key, tf := range postings {
+				if allowed[s.docs[key].hit.BoardID] {
+					scores[key] += float64(tf) * idf
+				}
+			}
+		}
+	}
+
+	hits := make([]searchHit, 0, len(scores))
+	for key, score := range scores {
+		hit := s.docs[key].hit
+		hit.Score = score
+		hits = append(hits, hit)
+	}
+	// Ties go to the lowest ID so results are stable
+	sort.Slice(hits, func(i, j int) bool { return hits[i].ID < hits[j].ID })
+	return rankHits(hits, limit), nil
+}
+
+// watchSearchWrites marks the memory index stale whenever boards or items are
+// written. Writes that don't carry a single model ID reload the whole index.
+// A write in a transaction is only marked once the transaction commits:
+// marked any earlier, a search in between would reload the old row and
+// consider it fresh.
+func watchSearchWrites(db *gorm.DB, index *memorySearchIndex) error {
+	pool := &searchConnPool{ConnPool: db.ConnPool, index: index}
+	db.ConnPool = pool
+	db.Statement.ConnPool = pool
+
+	mark := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.Schema == nil {
+			return
+		}
+		var kind string
+		switch tx.Statement.Schema.Table {
+		case "boards":
+			kind = "board"
+		case "project_items":
+			kind = "item"
+		default:
+			return
+		}
+		var id uint
+		switch model := tx.Statement.Model.(type) {
+		case *models.Board:
+			id = model.ID
+		case *models.ProjectItem:
+			id = model.ID
+		}
+		if pending, ok := tx.Statement.ConnPool.(*searchTx); ok {
+			pending.markDirty(kind, id)
+			return
+		}
+		index.markDirty(kind, id)
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("search:create", mark); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("search:update", mark); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("search:delete", mark)
+}
+
+// searchConnPool is the database handle while the memory index is in use. It
+// begins searchTx transactions, which hold their writes' marks until commit.
+type searchConnPool struct {
+	gorm.ConnPool
+	index *memorySearchIndex
+}
+
+func (p *searchConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	beginner, ok := p.ConnPool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &searchTx{Tx: tx, index: p.index}, nil
+}
+
+// GetDBConn keeps gorm.DB.DB() working on the wrapped handle.
+func (p *searchConnPool) GetDBConn() (*sql.DB, error) {
+	if db, ok := p.ConnPool.(*sql.DB); ok {
+		return db, nil
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+type searchTx struct {
+	*sql.Tx
+	index *memorySearchIndex
+
+	mu      sync.Mutex
+	pending []searchDocKey
+}
+
+func (t *searchTx) markDirty(kind string, id uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, searchDocKey{kind, id})
+}
+
+// Commit commits, then marks what the transaction wrote. Marks from a
+// rolled-back savepoint are kept; they only cost a reload.
+func (t *searchTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range t.pending {
+		t.index.markDirty(key.Kind, key.ID)
+	}
+	t.pending = nil
+	return nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := map[string][]string{
+		"Fix the login bug":          {"fix", "the", "login", "bug"},
+		"API v2: rate-limit (x)":     {"api", "v2", "rate", "limit"},
+		"Überprüfung der Größe":      {"überprüfung", "der", "größe"},
+		"a b c":                      {},
+		"  release_2024...notes!!  ": {"release", "2024", "notes"},
+	}
+	for text, want := range tests {
+		if got := tokenize(text); !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("tokenize(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	if got := snippet("short"); got != "short" {
+		t.Errorf("snippet(short) = %q", got)
+	}
+	long := strings.Repeat("é", snippetLength+10)
+	got := snippet(long)
+	if !utf8.ValidString(got) {
+		t.Errorf("snippet cut a character in half: %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n != snippetLength+1 || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet has %d characters, want %d and an ellipsis", n, snippetLength+1)
+	}
+}
+
+// testSearchIndex is a memory index filled without a database.
+func testSearchIndex(docs map[searchDocKey][2]string, boardOf map[searchDocKey]uint) *memorySearchIndex {
+	s := &memorySearchIndex{docs: map[searchDocKey]*searchDoc{}, postings: map[string]map[searchDocKey]int{}}
+	for key, text := range docs {
+		s.put(key, searchHit{BoardID: boardOf[key], Name: text[0]}, text[0], text[1])
+	}
+	return s
+}
+
+func TestMemorySearchRanking(t *testing.T) {
+	item := func(id uint) searchDocKey { return searchDocKey{"item", id} }
+	index := testSearchIndex(map[searchDocKey][2]string{
+		item(1): {"Login page", "The login form on the start page"},
+		item(2): {"Password reset", "Send a login link by email"},
+		item(3): {"Logging", "Ship the logs to the collector"},
+		item(4): {"Login audit", "Secret board"},
+		item(5): {"Release notes", "Write the notes for the release"},
+		item(6): {"Dark mode", "Themes for the page"},
+	}, map[searchDocKey]uint{item(1): 1, item(2): 1, item(3): 1, item(4): 2, item(5): 1, item(6): 1})
+
+	ids := func(hits []searchHit) []uint {
+		out := []uint{}
+		for _, h := range hits {
+			out = append(out, h.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		query string
+		want  []uint
+	}{
+		// Twice in the name and description beats once in the description
+		{"login", []uint{1, 2}},
+		// The last word matches as a prefix: logging and logs are rarer
+		// than login, so item 3 comes first
+		{"log", []uint{3, 1, 2}},
+		// A rare word outweighs a common one
+		{"page dark", []uint{6, 1}},
+		{"release", []uint{5}},
+		{"nothing", []uint{}},
+	}
+	for _, tt := range tests {
+		hits, err := index.Search(tt.query, []uint{1}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(hits); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	hits, _ := index.Search("login", []uint{1, 2}, 1)
+	if len(hits) != 1 || hits[0].ID != 1 {
+		t.Errorf("Search with limit 1 = %v, want item 1", ids(hits))
+	}
+}
+
+func TestSearchWritesMarkedOnCommit(t *testing.T) {
+	db, _ := openFakeDB(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := newMemorySearchIndex(db)
+	index.rebuild = false
+	if err := watchSearchWrites(db, index); err != nil {
+		t.Fatal(err)
+	}
+	item, board := &models.ProjectItem{}, &models.Board{}
+	item.ID, board.ID = 5, 3
+	dirty := func() map[searchDocKey]bool {
+		index.mu.Lock()
+		defer index.mu.Unlock()
+		out := index.dirty
+		index.dirty = nil
+		return out
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(item).Update("name", "Renamed").Error; err != nil {
+			return err
+		}
+		if got := dirty(); len(got) != 0 {
+			t.Errorf("marked before commit: %v", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dirty(); !got[searchDocKey{"item", 5}] {
+		t.Errorf("after commit dirty = %v, want item 5", got)
+	}
+
+	errRollback := errors.New("roll back")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		tx.Model(board).Update("name", "Gone")
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	if got := dirty(); len(got) != 0 {
+		t.Errorf("after rollback dirty = %v, want nothing", got)
+	}
+
+	// A write outside a transaction runs in GORM's own and is marked when done
+	if err := db.Model(board).Update("name", "Kept").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := dirty(); !got[searchDocKey{"board", 3}] {
+		t.Errorf("after a plain write dirty = %v, want board 3", got)
+	}
+
+	if got, err := db.DB(); err != nil || got != sqlDB {
+		t.Errorf("DB() = %v, %v; want the wrapped handle", got, err)
+	}
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxItemDescription matches the size of ProjectItem.Description.
+const maxItemDescription = 255
+
+type boardTemplateRequest struct {
+	BoardID     uint   `json:"board_id" validate:"required"`
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type instantiateTemplateRequest struct {
+	Name         string `json:"title" validate:"required,min=3,max=100"`
+	Description  string `json:"description" validate:"max=255"`
+	IncludeItems *bool  `json:"include_items"` // defaults to true
+	StartDate    string `json:"start_date"`    // YYYY-MM-DD, due dates are shifted relative to it
+}
+
+type itemTemplateRequest struct {
+	BoardID     *uint    `json:"board_id"`
+	Name        string   `json:"name" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Checklist   []string `json:"checklist"`
+	Priority    string   `json:"priority" validate:"omitempty,oneof=low medium high"`
+}
+
+// checkTemplateText validates a name, sent as nameField, and a description
+// against the columns they end up in. The validate tags above are not
+// enforced on these routes.
+func checkTemplateText(nameField, name string, minName int, description string) error {
+	var fields []apperr.FieldError
+	if n := utf8.RuneCountInString(name); n < minName || n > 100 {
+		fields = append(fields, apperr.Field(nameField, fmt.Sprintf("must be %d to 100 characters long", minName)))
+	}
+	if utf8.RuneCountInString(description) > maxItemDescription {
+		fields = append(fields, apperr.Field("description", "must be at most 255 characters long"))
+	}
+	if len(fields) > 0 {
+		return apperr.Invalid("Invalid "+nameField+" or description", fields...)
+	}
+	return nil
+}
+
+// snapshotBoard captures a board's workflow, custom fields and live items for
+// a template. Archived items are left out.
+func snapshotBoard(db *gorm.DB, boardID uint) (models.BoardSnapshot, error) {
+	var snapshot models.BoardSnapshot
+
+	statuses, transitions, err := loadWorkflow(db, boardID)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.Statuses = statuses
+	snapshot.Transitions = transitions
+
+	if err := db.Where("board_id = ?", boardID).Order("position, id").Find(&snapshot.CustomFields).Error; err != nil {
+		return snapshot, err
+	}
+
+	var items []models.ProjectItem
+	if err := db.Where("board_id = ? AND archived_at IS NULL", boardID).Find(&items).Error; err != nil {
+		return snapshot, err
+	}
+	if err := attachCustomFields(items); err != nil {
+		return snapshot, err
+	}
+
+	// Due dates are stored relative to the day of the earliest one.
+	var anchor *time.Time
+	for _, item := range items {
+		if item.DueDate != nil && (anchor == nil || item.DueDate.Before(*anchor)) {
+			anchor = item.DueDate
+		}
+	}
+	var anchorDay time.Time
+	if anchor != nil {
+		anchorDay = anchor.UTC().Truncate(24 * time.Hour)
+	}
+
+	for _, item := range sortParentsFirst(items) {
+		t := models.TemplateItem{
+			Ref:          item.ID,
+			ParentRef:    item.ParentID,
+			Name:         item.Name,
+			Description:  item.Description,
+			Status:       item.Status,
+			Priority:     item.Priority,
+			Estimate:     item.Estimate,
+			CustomFields: item.CustomFields,
+		}
+		if item.DueDate != nil {
+			offset := int64(item.DueDate.Sub(anchorDay).Seconds())
+			t.DueOffset = &offset
+		}
+		snapshot.Items = append(snapshot.Items, t)
+	}
+	return snapshot, nil
+}
+
+// sortParentsFirst orders items so every parent comes before its subtasks.
+func sortParentsFirst(items []models.ProjectItem) []models.ProjectItem {
+	byID := make(map[uint]*models.ProjectItem, len(items))
+	for i := range items {
+		byID[items[i].ID] = &items[i]
+	}
+	depth := func(item *models.ProjectItem) int {
+		d := 0
+		for item.ParentID != nil {
+			parent, ok := byID[*item.ParentID]
+			if !ok {
+				break
+			}
+			item = parent
+			d++
+		}
+		return d
+	}
+
+	sorted := make([]models.ProjectItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return depth(&sorted[i]) < depth(&sorted[j]) })
+	return sorted
+}
+
+// restoreSnapshot applies a snapshot to a freshly created board. When start is
+// set, item due dates are shifted so the earliest one falls on that day.
+func restoreSnapshot(tx *gorm.DB, board *models.Board, snapshot models.BoardSnapshot, includeItems bool, start *time.Time) error {
+	if len(snapshot.Statuses) == 0 {
+		if err := createDefaultWorkflow(tx, board.ID); err != nil {
+			return err
+		}
+	} else {
+		statuses := make([]models.BoardStatus, len(snapshot.Statuses))
+		for i, s := range snapshot.Statuses {
+			statuses[i] = models.BoardStatus{
+				BoardID:   board.ID,
+				Key:       s.Key,
+				Name:      s.Name,
+				Category:  s.Category,
+				Position:  s.Position,
+				WIPLimit:  s.WIPLimit,
+				WIPPolicy: s.WIPPolicy,
+			}
+		}
+		if err := tx.Create(&statuses).Error; err != nil {
+			return err
+		}
+	}
+	for _, t := range snapshot.Transitions {
+		if err := tx.Create(&models.StatusTransition{BoardID: board.ID, From: t.From, To: t.To}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, f := range snapshot.CustomFields {
+		field := models.CustomField{
+			BoardID:   board.ID,
+			Key:       f.Key,
+			Name:      f.Name,
+			Type:      f.Type,
+			Options:   f.Options,
+			Required:  f.Required,
+			Min:       f.Min,
+			Max:       f.Max,
+			MaxLength: f.MaxLength,
+			Position:  f.Position,
+		}
+		if err := tx.Create(&field).Error; err != nil {
+			return err
+		}
+	}
+
+	if !includeItems {
+		return nil
+	}
+
+	anchor := time.Now().UTC().Truncate(24 * time.Hour)
+	if start != nil {
+		anchor = *start
+	}
+
+	newIDs := make(map[uint]uint, len(snapshot.Items))
+	for _, t := range snapshot.Items {
+		item := models.ProjectItem{
+			Name:        t.Name,
+			BoardID:     board.ID,
+			Description: t.Description,
+			Status:      t.Status,
+			Priority:    t.Priority,
+			Estimate:    t.Estimate,
+		}
+		if t.ParentRef != nil {
+			if id, ok := newIDs[*t.ParentRef]; ok {
+				item.ParentID = &id
+			}
+		}
+		if t.DueOffset != nil {
+			due := anchor.Add(time.Duration(*t.DueOffset) * time.Second)
+			item.DueDate = &due
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		newIDs[t.Ref] = item.ID
+
+		if err := saveCustomFieldValues(tx, board.ID, item.ID, t.CustomFields, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withChecklist appends a template's checklist to an item description, one
+// Markdown task per step. Items have no checklist of their own yet.
+func withChecklist(description string, checklist []string) string {
+	var list strings.Builder
+	for _, step := range checklist {
+		list.WriteString("\n- [ ] " + step)
+	}
+	return strings.TrimLeft(description+list.String(), "\n")
+}
+
+// applyItemTemplate fills in the parts of an item payload the client left empty.
+func applyItemTemplate(body *ItemRequestPayload, template *models.ItemTemplate) error {
+	if body.Description == "" {
+		body.Description = template.Description
+	}
+	body.Description = withChecklist(body.Description, template.Checklist)
+	// The template itself fits, but the client's own description may not
+	if utf8.RuneCountInString(body.Description) > maxItemDescription {
+		return errors.New("Description with the template checklist is too long")
+	}
+	if body.Priority == "" {
+		body.Priority = string(template.Priority)
+	}
+	return nil
+}
+
+// ✅ Save a board as a template
+func CreateBoardTemplate(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body boardTemplateRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	if body.Name == "" || body.BoardID == 0 {
+		var fields []apperr.FieldError
+		if body.Name == "" {
+			fields = append(fields, apperr.Field("name", "is required"))
+		}
+		if body.BoardID == 0 {
+			fields = append(fields, apperr.Field("board_id", "is required"))
+		}
+		return apperr.Invalid("name and board_id are required", fields...)
+	}
+	if err := checkTemplateText("name", body.Name, 1, body.Description); err != nil {
+		return err
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, body.BoardID).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	snapshot, err := snapshotBoard(config.DB, board.ID)
+	if err != nil {
+		return apperr.Internal("Could not read board", err)
+	}
+
+	template := models.BoardTemplate{
+		UserID:          currentUserID,
+		Name:            body.Name,
+		Description:     body.Description,
+		EstimateUnit:    board.EstimateUnit,
+		EnforceBlockers: board.EnforceBlockers,
+		Snapshot:        snapshot,
+	}
+	if err := config.DB.Create(&template).Error; err != nil {
+		return apperr.Internal("Could not create template", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+// ✅ List the caller's board templates
+func GetBoardTemplates(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	page, err := parsePageRequest(c, listOrder{}, "board_templates.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	var templates []models.BoardTemplate
+	if err := config.DB.Omit("snapshot").Where("user_id = ?", currentUserID).Scopes(page.Scope).Find(&templates).Error; err != nil {
+		return apperr.Internal("Could not fetch templates", err)
+	}
+	templates, hasMore := trimPage(templates, page.Limit)
+
+	var lastID uint
+	if len(templates) > 0 {
+		lastID = templates[len(templates)-1].ID
+	}
+	return c.JSON(page.result(templates, hasMore, nil, lastID))
+}
+
+// loadBoardTemplate fetches the caller's template from the :id param.
+// On failure it returns nil and the error to respond with.
+func loadBoardTemplate(c *fiber.Ctx) (*models.BoardTemplate, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var template models.BoardTemplate
+	if err := config.DB.Where("user_id = ?", currentUserID).First(&template, c.Params("id")).Error; err != nil {
+		return nil, apperr.NotFound("Template not found")
+	}
+	return &template, nil
+}
+
+// ✅ Get one board template
+func GetBoardTemplate(c *fiber.Ctx) error {
+	template, err := loadBoardTemplate(c)
+	if template == nil {
+		return err
+	}
+	return c.JSON(template)
+}
+
+// ✅ Delete a board template
+func DeleteBoardTemplate(c *fiber.Ctx) error {
+	template, err := loadBoardTemplate(c)
+	if template == nil {
+		return err
+	}
+	if err := config.DB.Delete(template).Error; err != nil {
+		return apperr.Internal("Could not delete template", err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Create a new board from a template
+func InstantiateBoardTemplate(c *fiber.Ctx) error {
+	template, err := loadBoardTemplate(c)
+	if template == nil {
+		return err
+	}
+
+	var body instantiateTemplateRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	if err := checkTemplateText("title", body.Name, 3, body.Description); err != nil {
+		return err
+	}
+
+	var start *time.Time
+	if body.StartDate != "" {
+		parsed, err := time.Parse(time.DateOnly, body.StartDate)
+		if err != nil {
+			return apperr.Invalid("Invalid start_date, use YYYY-MM-DD", apperr.Field("start_date", "must be YYYY-MM-DD"))
+		}
+		start = &parsed
+	}
+	includeItems := body.IncludeItems == nil || *body.IncludeItems
+
+	board := models.Board{
+		Name:            body.Name,
+		Description:     body.Description,
+		UserID:          template.UserID,
+		EstimateUnit:    template.EstimateUnit,
+		EnforceBlockers: template.EnforceBlockers,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&board).Error; err != nil {
+			return err
+		}
+		if err := watchBoard(tx, board.UserID, board.ID, models.WatchCreated); err != nil {
+			return err
+		}
+		return restoreSnapshot(tx, &board, template.Snapshot, includeItems, start)
+	})
+	if err != nil {
+		return apperr.Internal("Could not create board from template", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(board)
+}
+
+// ✅ Create an item template
+func CreateItemTemplate(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body itemTemplateRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	if err := checkTemplateText("name", body.Name, 1, body.Description); err != nil {
+		return err
+	}
+	switch models.ItemPriority(body.Priority) {
+	case "", models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+	default:
+		return apperr.Invalid("priority must be low, medium or high", apperr.Field("priority", "must be low, medium or high"))
+	}
+	// The checklist ends up in the item's description, so both must fit in it
+	if utf8.RuneCountInString(withChecklist(body.Description, body.Checklist)) > maxItemDescription {
+		return apperr.Invalid("The description and checklist are too long for an item",
+			apperr.Field("checklist", "must fit in the item description with the template's description, at most 255 characters"))
+	}
+	if body.BoardID != nil && !canAccessBoard(currentUserID, *body.BoardID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	template := models.ItemTemplate{
+		UserID:      currentUserID,
+		BoardID:     body.BoardID,
+		Name:        body.Name,
+		Description: body.Description,
+		Checklist:   body.Checklist,
+		Priority:    models.ItemPriority(body.Priority),
+	}
+	if err := config.DB.Create(&template).Error; err != nil {
+		return apperr.Internal("Could not create template", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+// ✅ List item templates the caller can use, optionally for one board (?board_id=)
+func GetItemTemplates(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	page, err := parsePageRequest(c, listOrder{}, "item_templates.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	query := config.DB.Where("user_id = ?", currentUserID)
+	if boardID := c.Query("board_id"); boardID != "" {
+		query = query.Where("board_id IS NULL OR board_id = ?", boardID)
+	}
+
+	var templates []models.ItemTemplate
+	if err := query.Scopes(page.Scope).Find(&templates).Error; err != nil {
+		return apperr.Internal("Could not fetch templates", err)
+	}
+	templates, hasMore := trimPage(templates, page.Limit)
+
+	var lastID uint
+	if len(templates) > 0 {
+		lastID = templates[len(templates)-1].ID
+	}
+	return c.JSON(page.result(templates, hasMore, nil, lastID))
+}
+
+// ✅ Delete an item template
+func DeleteItemTemplate(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	result := config.DB.Where("user_id = ?", currentUserID).Delete(&models.ItemTemplate{}, c.Params("id"))
+	if result.Error != nil {
+		return apperr.Internal("Could not delete template", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.NotFound("Template not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestApplyItemTemplate(t *testing.T) {
+	template := &models.ItemTemplate{Description: "Release", Checklist: []string{"Tag", "Notes"}, Priority: models.PriorityHigh}
+
+	var body ItemRequestPayload
+	if err := applyItemTemplate(&body, template); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Release\n- [ ] Tag\n- [ ] Notes"; body.Description != want || body.Priority != "high" {
+		t.Errorf("got %q %s, want %q high", body.Description, body.Priority, want)
+	}
+
+	// The client's own description replaces the template's, the checklist stays
+	body = ItemRequestPayload{Description: "Hotfix", Priority: "low"}
+	if err := applyItemTemplate(&body, template); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Hotfix\n- [ ] Tag\n- [ ] Notes"; body.Description != want || body.Priority != "low" {
+		t.Errorf("got %q %s, want %q low", body.Description, body.Priority, want)
+	}
+
+	if got := withChecklist("", []string{"Tag"}); got != "- [ ] Tag" {
+		t.Errorf("withChecklist without a description = %q", got)
+	}
+
+	// Lengths are in characters: this one is 255 of them in more bytes
+	body = ItemRequestPayload{Description: strings.Repeat("é", maxItemDescription-len("\n- [ ] Tag\n- [ ] Notes"))}
+	if err := applyItemTemplate(&body, template); err != nil {
+		t.Errorf("a description that fits exactly: %v", err)
+	}
+
+	body = ItemRequestPayload{Description: strings.Repeat("x", maxItemDescription-10)}
+	if err := applyItemTemplate(&body, template); err == nil {
+		t.Error("a description that no longer fits was accepted")
+	}
+}
+
+func TestCheckTemplateText(t *testing.T) {
+	tests := []struct {
+		name, description string
+		min               int
+		ok                bool
+	}{
+		{"Sprint", "", 3, true},
+		{"ab", "", 3, false},
+		{strings.Repeat("ü", 100), strings.Repeat("ü", 255), 3, true},
+		{strings.Repeat("ü", 101), "", 3, false},
+		{"Sprint", strings.Repeat("ü", 256), 3, false},
+		{"", "", 1, false},
+	}
+	for _, tt := range tests {
+		if err := checkTemplateText("title", tt.name, tt.min, tt.description); (err == nil) != tt.ok {
+			t.Errorf("checkTemplateText(%d characters, %d characters) = %v", len([]rune(tt.name)), len([]rune(tt.description)), err)
+		}
+	}
+}
+
+// Archived items stay out of a template.
+func TestSnapshotBoardSkipsArchivedItems(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	if _, err := snapshotBoard(db, 3); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, statement := range fake.sent() {
+		if strings.HasPrefix(statement, "SELECT * FROM `project_items`") {
+			found = strings.Contains(statement, "board_id = 3 AND archived_at IS NULL")
+		}
+	}
+	if !found {
+		t.Error("the item lookup includes archived items")
+	}
+}
+
+func TestGetItemTemplatesPages(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	fake.answer([]string{"id", "user_id", "name"},
+		[]driver.Value{int64(4), int64(7), "Bug"},
+		[]driver.Value{int64(5), int64(7), "Release"})
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Locals("user_id", uint(7))
+		return GetItemTemplates(c)
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/?limit=1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var page struct {
+		Data       []models.ItemTemplate `json:"data"`
+		NextCursor string                `json:"next_cursor"`
+		HasMore    bool                  `json:"has_more"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Data) != 1 || page.Data[0].ID != 4 || !page.HasMore || page.NextCursor == "" {
+		t.Errorf("page = %+v, want template 4 and a cursor", page)
+	}
+	if sent := fake.sent(); len(sent) != 1 || !strings.HasSuffix(sent[0], "ORDER BY item_templates.id ASC LIMIT 2") {
+		t.Errorf("sent %q, want one extra row fetched", sent)
+	}
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// trashRetention is how long deleted boards and items stay restorable before
+// the purge job removes them for good (TRASH_RETENTION, default 30 days).
+func trashRetention() time.Duration {
+	retention, err := time.ParseDuration(utils.GetEnv("TRASH_RETENTION", "720h"))
+	if err != nil || retention <= 0 {
+		return 720 * time.Hour
+	}
+	return retention
+}
+
+// trashedBoard and trashedItem are trash entries, with when they will be purged.
+type trashedBoard struct {
+	models.Board
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+type trashedItem struct {
+	models.ProjectItem
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// applyArchivedQuery handles ?archived=false (the default), true or all on
+// listings of table.
+func applyArchivedQuery(c *fiber.Ctx, query *gorm.DB, table string) (*gorm.DB, error) {
+	switch c.Query("archived", "false") {
+	case "false":
+		return query.Where(table + ".archived_at IS NULL"), nil
+	case "true":
+		return query.Where(table + ".archived_at IS NOT NULL"), nil
+	case "all":
+		return query, nil
+	default:
+		return nil, errors.New("archived must be true, false or all")
+	}
+}
+
+// newTrashBatch names one trip to the trash. Everything deleted at once gets
+// the same batch, so restoring or purging what was deleted takes exactly those
+// rows along.
+func newTrashBatch() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// trashUpdate is the update that moves rows to the trash in batch.
+func trashUpdate(tx *gorm.DB, batch string) map[string]interface{} {
+	return map[string]interface{}{"deleted_at": tx.NowFunc(), "trash_batch": batch}
+}
+
+// softDeleteBoard moves a board and its items to the trash in one batch.
+// Items already in the trash keep their own batch and stay there when the
+// board is restored.
+func softDeleteBoard(tx *gorm.DB, board *models.Board) error {
+	batch := newTrashBatch()
+	if err := tx.Model(&models.ProjectItem{}).Where("board_id = ?", board.ID).Updates(trashUpdate(tx, batch)).Error; err != nil {
+		return err
+	}
+	return tx.Model(board).Updates(trashUpdate(tx, batch)).Error
+}
+
+// trashItems moves items, usually one with its subtasks, to the trash in one batch.
+func trashItems(tx *gorm.DB, ids []uint) error {
+	return tx.Model(&models.ProjectItem{}).Where("id IN ?", ids).Updates(trashUpdate(tx, newTrashBatch())).Error
+}
+
+// inTrashBatch finds the trashed items of a board deleted in batch. Items
+// trashed before batches were recorded have none; for those, an equal
+// deleted_at is the best guess.
+func inTrashBatch(boardID uint, batch string, deletedAt time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Unscoped().Model(&models.ProjectItem{}).Where("board_id = ? AND deleted_at IS NOT NULL", boardID)
+		if batch == "" {
+			return db.Where("trash_batch = '' AND deleted_at = ?", deletedAt)
+		}
+		return db.Where("trash_batch = ?", batch)
+	}
+}
+
+// restoreUpdate is the update that takes rows out of the trash.
+func restoreUpdate() map[string]interface{} {
+	return map[string]interface{}{"deleted_at": nil, "trash_batch": ""}
+}
+
+// purgeItems permanently removes items and everything hanging off them.
+// Surviving subtasks of purged items become top-level items.
+func purgeItems(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx = tx.Unscoped()
+	steps := []func() error{
+		func() error { return tx.Where("item_id IN ?", ids).Delete(&models.CustomFieldValue{}).Error },
+		func() error {
+			return tx.Where("blocker_id IN ? OR blocked_id IN ?", ids, ids).Delete(&models.ItemDependency{}).Error
+		},
+		func() error { return tx.Where("item_id IN ?", ids).Delete(&models.Worklog{}).Error },
+		func() error { return tx.Where("item_id IN ?", ids).Delete(&models.Watch{}).Error },
+		func() error { return tx.Where("item_id IN ?", ids).Delete(&models.WIPOverride{}).Error },
+		func() error { return tx.Where("item_id IN ?", ids).Delete(&models.RecentView{}).Error },
+		func() error {
+			return tx.Model(&models.ItemRecurrence{}).Where("current_item_id IN ?", ids).Update("active", false).Error
+		},
+		func() error {
+			return tx.Model(&models.ProjectItem{}).Where("parent_id IN ? AND id NOT IN ?", ids, ids).Update("parent_id", nil).Error
+		},
+		func() error { return tx.Delete(&models.ProjectItem{}, ids).Error },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeBoard permanently removes a board, its items and its configuration.
+func purgeBoard(tx *gorm.DB, boardID uint) error {
+	tx = tx.Unscoped()
+	var itemIDs []uint
+	if err := tx.Model(&models.ProjectItem{}).Where("board_id = ?", boardID).Pluck("id", &itemIDs).Error; err != nil {
+		return err
+	}
+	if err := purgeItems(tx, itemIDs); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{
+		&models.CustomField{},
+		&models.BoardStatus{},
+		&models.StatusTransition{},
+		&models.WIPOverride{},
+		&models.Watch{},
+		&models.ItemTemplate{},
+		&models.SavedView{},
+		&models.BoardStar{},
+		&models.RecentView{},
+		&models.BoardMember{},
+	} {
+		if err := tx.Where("board_id = ?", boardID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&models.Board{}, boardID).Error
+}
+
+// runTrashPurge removes everything that has been in the trash longer than the retention.
+func runTrashPurge() {
+	cutoff := time.Now().Add(-trashRetention())
+
+	var boardIDs []uint
+	if err := config.DB.Unscoped().Model(&models.Board{}).Where("deleted_at < ?", cutoff).Pluck("id", &boardIDs).Error; err != nil {
+		log.Println("Trash purge: could not load boards:", err)
+		return
+	}
+	for _, id := range boardIDs {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error { return purgeBoard(tx, id) }); err != nil {
+			log.Printf("Trash purge: board %d: %v", id, err)
+		}
+	}
+
+	var itemIDs []uint
+	if err := config.DB.Unscoped().Model(&models.ProjectItem{}).Where("deleted_at < ?", cutoff).Pluck("id", &itemIDs).Error; err != nil {
+		log.Println("Trash purge: could not load items:", err)
+		return
+	}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error { return purgeItems(tx, itemIDs) }); err != nil {
+		log.Println("Trash purge: items:", err)
+	}
+}
+
+// StartTrashPurger empties old trash in the background.
+func StartTrashPurger() {
+	interval, err := time.ParseDuration(utils.GetEnv("TRASH_PURGE_INTERVAL", "24h"))
+	if err != nil || interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runTrashPurge()
+		}
+	}()
+}
+
+// ✅ Archive a board (only owner)
+func ArchiveBoard(c *fiber.Ctx) error {
+	return setBoardArchived(c, true)
+}
+
+// ✅ Bring an archived board back (only owner)
+func UnarchiveBoard(c *fiber.Ctx) error {
+	return setBoardArchived(c, false)
+}
+
+func setBoardArchived(c *fiber.Ctx, archived bool) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if board.UserID != currentUserID {
+		return apperr.Forbidden("You do not own this board")
+	}
+
+	board.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		board.ArchivedAt = &now
+	}
+	if err := config.DB.Model(&board).Update("archived_at", board.ArchivedAt).Error; err != nil {
+		return apperr.Internal("Could not update board", err)
+	}
+
+	return c.JSON(board)
+}
+
+// ✅ Archive an item
+func ArchiveProjectItem(c *fiber.Ctx) error {
+	return setItemArchived(c, true)
+}
+
+// ✅ Bring an archived item back
+func UnarchiveProjectItem(c *fiber.Ctx) error {
+	return setItemArchived(c, false)
+}
+
+func setItemArchived(c *fiber.Ctx, archived bool) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return apperr.Invalid("Invalid ID format")
+	}
+	item, err := loadAccessibleItem(currentUserID, uint(id))
+	if item == nil {
+		return err
+	}
+
+	item.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		item.ArchivedAt = &now
+	}
+	if err := config.DB.Model(item).Update("archived_at", item.ArchivedAt).Error; err != nil {
+		return apperr.Internal("Could not update item", err)
+	}
+
+	return c.JSON(item)
+}
+
+// ✅ List the caller's deleted boards, and the deleted items on boards they can access, with when they will be purged
+func GetTrash(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var boards []models.Board
+	err := config.DB.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", currentUserID).
+		Order("deleted_at DESC").
+		Find(&boards).Error
+	if err != nil {
+		return apperr.Internal("Could not fetch trash", err)
+	}
+
+	// Items deleted along with a board are restored with it, so only list
+	// items deleted on their own.
+	boardIDs, err := accessibleBoardIDs(currentUserID)
+	if err != nil {
+		return apperr.Internal("Could not fetch trash", err)
+	}
+	items := []models.ProjectItem{}
+	if len(boardIDs) > 0 {
+		err = config.DB.Unscoped().
+			Where("board_id IN ? AND deleted_at IS NOT NULL", boardIDs).
+			Order("deleted_at DESC").
+			Find(&items).Error
+		if err != nil {
+			return apperr.Internal("Could not fetch trash", err)
+		}
+	}
+
+	retention := trashRetention()
+	trashedBoards := make([]trashedBoard, len(boards))
+	for i, b := range boards {
+		trashedBoards[i] = trashedBoard{Board: b, PurgeAt: b.DeletedAt.Time.Add(retention)}
+	}
+	trashedItems := make([]trashedItem, len(items))
+	for i, item := range items {
+		trashedItems[i] = trashedItem{ProjectItem: item, PurgeAt: item.DeletedAt.Time.Add(retention)}
+	}
+
+	return c.JSON(fiber.Map{
+		"boards": trashedBoards,
+		"items":  trashedItems,
+	})
+}
+
+// loadTrashedBoard fetches a deleted board owned by the caller.
+// On failure it returns a nil board and the error to respond with.
+func loadTrashedBoard(c *fiber.Ctx) (*models.Board, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&board, c.Params("id")).Error; err != nil {
+		return nil, apperr.NotFound("Board not found in trash")
+	}
+	if board.UserID != currentUserID {
+		return nil, apperr.Forbidden("You do not own this board")
+	}
+	return &board, nil
+}
+
+// loadTrashedItem fetches a deleted item on a board where allowed lets the
+// caller restore or purge it.
+// On failure it returns a nil item and the error to respond with.
+func loadTrashedItem(c *fiber.Ctx, allowed func(userID, boardID uint) bool) (*models.ProjectItem, error) {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return nil, apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var item models.ProjectItem
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&item, c.Params("id")).Error; err != nil {
+		return nil, apperr.NotFound("Item not found in trash")
+	}
+	var board models.Board
+	if err := config.DB.Unscoped().First(&board, item.BoardID).Error; err != nil {
+		return nil, apperr.Forbidden("You do not have access to this board")
+	}
+	if board.DeletedAt.Valid {
+		if board.UserID != currentUserID {
+			return nil, apperr.Forbidden("You do not have access to this board")
+		}
+		return nil, apperr.Conflict("The item's board is in the trash, restore or purge the board instead").
+			WithCode(codeBoardInTrash).With("board_id", board.ID)
+	}
+	if !allowed(currentUserID, board.ID) {
+		return nil, apperr.Forbidden("You do not have access to this board")
+	}
+	return &item, nil
+}
+
+// ✅ Restore a deleted board with the items deleted along with it
+func RestoreBoard(c *fiber.Ctx) error {
+	board, err := loadTrashedBoard(c)
+	if board == nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(inTrashBatch(board.ID, board.TrashBatch, board.DeletedAt.Time)).Updates(restoreUpdate()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(board).Updates(restoreUpdate()).Error
+	})
+	if err != nil {
+		return apperr.Internal("Could not restore board", err)
+	}
+
+	return c.JSON(board)
+}
+
+// ✅ Restore a deleted item with the subtasks deleted along with it (board members)
+func RestoreProjectItem(c *fiber.Ctx) error {
+	item, err := loadTrashedItem(c, canAccessBoard)
+	if item == nil {
+		return err
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var items []models.ProjectItem
+		if err := tx.Scopes(inTrashBatch(item.BoardID, item.TrashBatch, item.DeletedAt.Time)).Find(&items).Error; err != nil {
+			return err
+		}
+		restored, err := restoredStatuses(tx, item.BoardID, items, utils.GetIDFromContext(c))
+		if err != nil {
+			return err
+		}
+		for status, ids := range restored {
+			err := tx.Unscoped().Model(&models.ProjectItem{}).Where("id IN ?", ids).
+				Updates(map[string]interface{}{"deleted_at": nil, "trash_batch": "", "status": status}).Error
+			if err != nil {
+				return err
+			}
+		}
+		// A parent that is still in the trash can't hold restored subtasks.
+		ids := make([]uint, len(items))
+		for i := range items {
+			ids[i] = items[i].ID
+		}
+		var orphans []uint
+		err = tx.Model(&models.ProjectItem{}).
+			Where("id IN ? AND parent_id IS NOT NULL", ids).
+			Where("NOT EXISTS (SELECT 1 FROM project_items AS p WHERE p.id = project_items.parent_id AND p.deleted_at IS NULL)").
+			Pluck("id", &orphans).Error
+		if err != nil || len(orphans) == 0 {
+			return err
+		}
+		return tx.Model(&models.ProjectItem{}).Where("id IN ?", orphans).Update("parent_id", nil).Error
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not restore item", err)
+	}
+
+	config.DB.First(item, item.ID)
+	return c.JSON(item)
+}
+
+// restoredStatuses groups the items coming back from the trash by the status
+// they return to. Statuses removed from the workflow meanwhile fall back to
+// the board's initial status, and every status must have room for its items
+// under a hard WIP limit.
+func restoredStatuses(tx *gorm.DB, boardID uint, items []models.ProjectItem, userID uint) (map[models.ItemStatus][]uint, error) {
+	statuses, _, err := loadWorkflow(tx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := initialStatus(tx, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var landing []models.ItemStatus
+	restored := map[models.ItemStatus][]uint{}
+	for _, item := range items {
+		status := item.Status
+		if len(statuses) > 0 && findStatus(statuses, string(status)) == nil {
+			status = initial
+		}
+		if restored[status] == nil {
+			landing = append(landing, status)
+		}
+		restored[status] = append(restored[status], item.ID)
+	}
+	for _, status := range landing {
+		check, _, err := evaluateWIPFor(tx, boardID, status, int64(len(restored[status])), userID, "")
+		if err != nil {
+			return nil, wipError(check, err, "Could not restore item")
+		}
+	}
+	return restored, nil
+}
+
+// ✅ Permanently delete a board from the trash
+func PurgeBoard(c *fiber.Ctx) error {
+	board, err := loadTrashedBoard(c)
+	if board == nil {
+		return err
+	}
+
+	if err := config.DB.Transaction(func(tx *gorm.DB) error { return purgeBoard(tx, board.ID) }); err != nil {
+		return apperr.Internal("Could not purge board", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Permanently delete an item, and the subtasks deleted with it, from the trash (board admins)
+func PurgeProjectItem(c *fiber.Ctx) error {
+	item, err := loadTrashedItem(c, canAdminBoard)
+	if item == nil {
+		return err
+	}
+
+	// Subtasks deleted along with the item go with it
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Scopes(inTrashBatch(item.BoardID, item.TrashBatch, item.DeletedAt.Time)).Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		return purgeItems(tx, ids)
+	})
+	if err != nil {
+		return apperr.Internal("Could not purge item", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/models"
+	"gorm.io/gorm"
+)
+
+func TestTrashBatches(t *testing.T) {
+	db, fake := openFakeDB(t)
+
+	if err := trashItems(db, []uint{4, 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := trashItems(db, []uint{6}); err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.sent()
+	batch := func(statement string) string {
+		i := strings.Index(statement, "`trash_batch`=")
+		if i < 0 {
+			t.Fatalf("no batch in %s", statement)
+		}
+		return strings.SplitN(statement[i:], ",", 2)[0]
+	}
+	if len(sent) != 2 || !strings.Contains(sent[0], "id IN (4,5)") || !strings.Contains(sent[0], "`deleted_at` IS NULL") {
+		t.Fatalf("trashItems sent %q", sent)
+	}
+	// Items deleted at the same moment are still told apart
+	if batch(sent[0]) == batch(sent[1]) {
+		t.Errorf("two deletes share %s", batch(sent[0]))
+	}
+
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	find := func(batch string) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			var ids []uint
+			return tx.Scopes(inTrashBatch(3, batch, deletedAt)).Pluck("id", &ids)
+		})
+	}
+	if got := find("abc"); !strings.Contains(got, "trash_batch = 'abc'") || strings.Contains(got, "deleted_at = ") {
+		t.Errorf("batch lookup = %s, want it to match the batch only", got)
+	}
+	// Rows trashed before batches were recorded
+	if got := find(""); !strings.Contains(got, "trash_batch = '' AND deleted_at = ") {
+		t.Errorf("legacy lookup = %s, want it to match deleted_at", got)
+	}
+
+	board := &models.Board{}
+	board.ID = 3
+	if err := softDeleteBoard(db, board); err != nil {
+		t.Fatal(err)
+	}
+	sent = fake.sent()
+	if len(sent) != 2 || batch(sent[0]) != batch(sent[1]) {
+		t.Errorf("a board and its items are in different batches: %q", sent)
+	}
+}
+
+func TestRestoredStatuses(t *testing.T) {
+	db, fake := openFakeDB(t)
+	statusCols := []string{"id", "board_id", "status_key", "category", "wip_limit", "wip_policy"}
+	workflow := func() {
+		for i := 0; i < 2; i++ { // loadWorkflow, then initialStatus
+			fake.answer(statusCols,
+				[]driver.Value{int64(1), int64(3), "backlog", string(models.CategoryNotStarted), nil, ""},
+				[]driver.Value{int64(2), int64(3), "doing", string(models.CategoryActive), int64(2), string(models.WIPHard)})
+			fake.answer([]string{"id"})
+		}
+	}
+	items := []models.ProjectItem{{Status: "doing"}, {Status: "review"}}
+	items[0].ID, items[1].ID = 4, 5
+
+	// review left the workflow while the item was in the trash
+	workflow()
+	fake.answer(statusCols, []driver.Value{int64(2), int64(3), "doing", string(models.CategoryActive), int64(2), string(models.WIPHard)})
+	fake.answer([]string{"count"}, []driver.Value{int64(1)})
+	restored, err := restoredStatuses(db, 3, items, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 || len(restored["doing"]) != 1 || len(restored["backlog"]) != 1 || restored["backlog"][0] != 5 {
+		t.Errorf("restored = %v, want 4 back in doing and 5 in backlog", restored)
+	}
+
+	// A full column stays full
+	fake.sent()
+	workflow()
+	fake.answer(statusCols, []driver.Value{int64(2), int64(3), "doing", string(models.CategoryActive), int64(2), string(models.WIPHard)})
+	fake.answer([]string{"count"}, []driver.Value{int64(2)})
+	_, err = restoredStatuses(db, 3, items[:1], 1)
+	var conflict *apperr.Error
+	if !errors.As(err, &conflict) || conflict.Code != codeWIPLimitReached {
+		t.Errorf("err = %v, want the WIP limit refusal", err)
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Boards and items carry a version that goes up by one on every write. It is
+// sent as the ETag, and a client that sends it back in If-Match only
+// overwrites the version it read.
+var requireIfMatch = utils.GetEnv("IF_MATCH_REQUIRED", "false") == "true"
+
+// errVersionConflict is a write of a loaded board or item that lost a race:
+// the row changed after it was read.
+var errVersionConflict = errors.New("the row changed since it was read")
+
+const versionColumn = "version"
+
+// etag is the entity tag of a version.
+func etag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+func setETag(c *fiber.Ctx, version uint) {
+	c.Set(fiber.HeaderETag, etag(version))
+}
+
+// checkIfMatch compares the If-Match header with the version about to be
+// changed. Without the header the write goes ahead unless IF_MATCH_REQUIRED is
+// set; a tag for another version, or a weak one, is errVersionConflict.
+func checkIfMatch(c *fiber.Ctx, version uint) error {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		if requireIfMatch {
+			return apperr.PreconditionRequired("Send If-Match with the ETag of the version you are changing")
+		}
+		return nil
+	}
+	if header == "*" {
+		return nil
+	}
+	for _, tag := range strings.Split(header, ",") {
+		// If-Match compares strongly, so a weak tag never matches
+		if strings.TrimSpace(tag) == etag(version) {
+			return nil
+		}
+	}
+	return errVersionConflict
+}
+
+// staleWrite is the 412 for a write based on an old version, with the current
+// representation and its ETag so the client can merge and retry.
+func staleWrite(c *fiber.Ctx, version uint, current interface{}) error {
+	setETag(c, version)
+	return apperr.PreconditionFailed("The resource has changed since you read it; merge with current and retry").
+		With(versionColumn, version).With("current", current)
+}
+
+// claimVersion bumps a row's version only if it is still version, so a delete
+// can't remove a row someone changed after the caller read it.
+func claimVersion(tx *gorm.DB, model interface{}, id, version uint) error {
+	result := tx.Model(model).Where("id = ? AND version = ?", id, version).
+		Update(versionColumn, gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// InitVersioning makes every update of a board or item bump its version.
+// Writing a loaded struct only applies while the row still has the version
+// that was loaded; otherwise the write fails with errVersionConflict.
+func InitVersioning(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("version:create", startVersion); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("version:bump", bumpVersion); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:update").Register("version:check", checkVersion)
+}
+
+// startVersion gives new rows version 1 in memory as well as in the table.
+func startVersion(tx *gorm.DB) {
+	field := versionField(tx)
+	if field == nil {
+		return
+	}
+	ctx := tx.Statement.Context
+	set := func(value reflect.Value) {
+		if _, zero := field.ValueOf(ctx, value); zero {
+			tx.AddError(field.Set(ctx, value, uint(1)))
+		}
+	}
+	switch value := tx.Statement.ReflectValue; value.Kind() {
+	case reflect.Struct:
+		set(value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			set(reflect.Indirect(value.Index(i)))
+		}
+	}
+}
+
+func bumpVersion(tx *gorm.DB) {
+	field := versionField(tx)
+	if field == nil {
+		return
+	}
+	stmt := tx.Statement
+
+	// Column updates (archive, bulk edits, claimVersion) bump in SQL
+	if dest, ok := stmt.Dest.(map[string]interface{}); ok {
+		if _, set := dest[versionColumn]; !set {
+			dest[versionColumn] = gorm.Expr("version + 1")
+			selectVersion(stmt)
+			if loaded, ok := loadedVersion(tx, field); ok {
+				tx.InstanceSet("version:bumped", loaded)
+			}
+		}
+		return
+	}
+
+	// A loaded struct is written only over the version it was loaded at
+	loaded, ok := loadedVersion(tx, field)
+	if !ok || stmt.ReflectValue.Kind() != reflect.Struct {
+		return
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: versionColumn}, Value: loaded},
+	}})
+	stmt.SetColumn(versionColumn, loaded+1, true)
+	selectVersion(stmt)
+	tx.InstanceSet("version:expected", loaded)
+}
+
+func checkVersion(tx *gorm.DB) {
+	field := versionField(tx)
+	if field == nil || tx.Error != nil {
+		return
+	}
+	ctx := tx.Statement.Context
+	if loaded, ok := tx.InstanceGet("version:expected"); ok && tx.RowsAffected == 0 {
+		tx.AddError(field.Set(ctx, tx.Statement.ReflectValue, loaded))
+		tx.AddError(errVersionConflict)
+		return
+	}
+	// The SQL bump left the loaded struct behind; catch it up
+	if loaded, ok := tx.InstanceGet("version:bumped"); ok && tx.RowsAffected > 0 {
+		tx.AddError(field.Set(ctx, tx.Statement.ReflectValue, loaded.(uint)+1))
+	}
+}
+
+// versionField is the version field of the statement's model, if it has one.
+func versionField(tx *gorm.DB) *schema.Field {
+	if tx.Statement.Schema == nil {
+		return nil
+	}
+	return tx.Statement.Schema.LookUpField(versionColumn)
+}
+
+// loadedVersion reads the version of the struct being updated. Zero means it
+// wasn't loaded from the table, as in Model(&models.ProjectItem{}).
+func loadedVersion(tx *gorm.DB, field *schema.Field) (uint, bool) {
+	value := tx.Statement.ReflectValue
+	if value.Kind() != reflect.Struct {
+		return 0, false
+	}
+	version, zero := field.ValueOf(tx.Statement.Context, value)
+	if zero {
+		return 0, false
+	}
+	return version.(uint), true
+}
+
+// selectVersion keeps the version in an update restricted with Select.
+func selectVersion(stmt *gorm.Statement) {
+	if len(stmt.Selects) == 0 {
+		return
+	}
+	for _, column := range stmt.Selects {
+		if column == "*" || column == versionColumn {
+			return
+		}
+	}
+	stmt.Selects = append(stmt.Selects, versionColumn)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestVersioningCallbacks(t *testing.T) {
+	db, fake := openFakeDB(t)
+	if err := InitVersioning(db); err != nil {
+		t.Fatal(err)
+	}
+
+	board := &models.Board{Name: "Roadmap"}
+	if err := db.Create(board).Error; err != nil {
+		t.Fatal(err)
+	}
+	if board.Version != 1 {
+		t.Errorf("a new board has version %d, want 1", board.Version)
+	}
+	fake.sent()
+
+	// Saving a loaded board only overwrites the version it was loaded at
+	board.ID, board.Version = 4, 3
+	if err := db.Save(board).Error; err != nil {
+		t.Fatal(err)
+	}
+	sent := fake.sent()
+	if len(sent) != 1 || !strings.Contains(sent[0], "`version`=4,") || !strings.Contains(sent[0], "`boards`.`version` = 3") {
+		t.Errorf("save sent %q, want version 4 where it is 3", sent)
+	}
+	if board.Version != 4 {
+		t.Errorf("after a save version = %d, want 4", board.Version)
+	}
+
+	// Someone else got there first
+	fake.affects(0)
+	err := db.Save(board).Error
+	if !errors.Is(err, errVersionConflict) || board.Version != 4 {
+		t.Errorf("a lost race: err = %v, version = %d; want errVersionConflict and 4", err, board.Version)
+	}
+	fake.sent()
+
+	// A column update bumps in SQL and catches the struct up
+	if err := db.Model(board).Update("name", "Plans").Error; err != nil {
+		t.Fatal(err)
+	}
+	if sent := fake.sent(); len(sent) != 1 || !strings.Contains(sent[0], "`version`=version + 1") {
+		t.Errorf("update sent %q, want version + 1", sent)
+	}
+	if board.Version != 5 {
+		t.Errorf("after an update version = %d, want 5", board.Version)
+	}
+
+	// Rows that were never loaded are bumped without a check
+	if err := db.Model(&models.ProjectItem{}).Where("board_id = ?", 4).Update("status", "done").Error; err != nil {
+		t.Fatal(err)
+	}
+	if sent := fake.sent(); len(sent) != 1 || !strings.Contains(sent[0], "`version`=version + 1") || strings.Contains(sent[0], "`version` =") {
+		t.Errorf("bulk update sent %q", sent)
+	}
+
+	fake.affects(0)
+	if err := claimVersion(db, &models.ProjectItem{}, 9, 2); !errors.Is(err, errVersionConflict) {
+		t.Errorf("claimVersion of a changed row: err = %v", err)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := map[string]error{
+		"":                nil,
+		"*":               nil,
+		`"3"`:             nil,
+		`W/"3"`:           errVersionConflict, // If-Match compares strongly
+		`"1", "3"`:        nil,
+		`W/"3", "3"`:      nil,
+		`"2"`:             errVersionConflict,
+		`3`:               errVersionConflict,
+		`"2", W/"4", "5"`: errVersionConflict,
+	}
+	for header, want := range tests {
+		var got error
+		app := fiber.New()
+		app.Put("/", func(c *fiber.Ctx) error {
+			got = checkIfMatch(c, 3)
+			return nil
+		})
+		req := httptest.NewRequest("PUT", "/", nil)
+		if header != "" {
+			req.Header.Set(fiber.HeaderIfMatch, header)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+		if !errors.Is(got, want) && got != want {
+			t.Errorf("If-Match %s: err = %v, want %v", header, got, want)
+		}
+	}
+}
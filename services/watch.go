@@ -0,0 +1,274 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type watchRequest struct {
+	Events []string `json:"events"` // omit to use the caller's defaults
+}
+
+func parseWatchEvents(values []string) ([]models.WatchEvent, error) {
+	events := make([]models.WatchEvent, 0, len(values))
+	for _, v := range values {
+		event := models.WatchEvent(v)
+		switch event {
+		case models.EventStatusChange, models.EventComment, models.EventDueDate:
+			events = append(events, event)
+		default:
+			return nil, fmt.Errorf("Unknown event %q, use status_change, comment or due_date", v)
+		}
+	}
+	return events, nil
+}
+
+// watchItem makes the user watch an item. Existing watches are left as they are,
+// so automatic watching never overrides a user's own choices.
+func watchItem(db *gorm.DB, userID, itemID uint, reason models.WatchReason) error {
+	watch := models.Watch{UserID: userID, ItemID: &itemID, Reason: reason}
+	return db.Where("user_id = ? AND item_id = ?", userID, itemID).FirstOrCreate(&watch).Error
+}
+
+// watchBoard makes the user watch every item on a board.
+func watchBoard(db *gorm.DB, userID, boardID uint, reason models.WatchReason) error {
+	watch := models.Watch{UserID: userID, BoardID: &boardID, Reason: reason}
+	return db.Where("user_id = ? AND board_id = ?", userID, boardID).FirstOrCreate(&watch).Error
+}
+
+// subscribersFor returns the users who should hear about event on an item:
+// watchers of the item or its board whose preferences include the event.
+// An item watch's preferences win over a board watch's. Watchers who no
+// longer own or belong to the board are left out.
+func subscribersFor(itemID uint, event models.WatchEvent) ([]uint, error) {
+	var item models.ProjectItem
+	if err := config.DB.First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+	var board models.Board
+	if err := config.DB.First(&board, item.BoardID).Error; err != nil {
+		return nil, err
+	}
+
+	var watches []models.Watch
+	members := config.DB.Model(&models.BoardMember{}).Select("user_id").Where("board_id = ?", board.ID)
+	err := config.DB.Where("item_id = ? OR board_id = ?", item.ID, item.BoardID).
+		Where("user_id = ? OR user_id IN (?)", board.UserID, members).
+		Order("item_id IS NULL").
+		Find(&watches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(watches))
+	for _, w := range watches {
+		userIDs = append(userIDs, w.UserID)
+	}
+	var defaults []models.SubscriptionDefaults
+	if err := config.DB.Where("user_id IN ?", userIDs).Find(&defaults).Error; err != nil {
+		return nil, err
+	}
+	defaultsByUser := make(map[uint][]models.WatchEvent, len(defaults))
+	for _, d := range defaults {
+		defaultsByUser[d.UserID] = d.Events
+	}
+
+	seen := map[uint]bool{}
+	var subscribers []uint
+	for _, w := range watches {
+		if seen[w.UserID] {
+			continue // item watch already decided for this user
+		}
+		seen[w.UserID] = true
+
+		events := w.Events
+		if len(events) == 0 {
+			events = defaultsByUser[w.UserID]
+		}
+		if events == nil {
+			events = models.AllWatchEvents
+		}
+		for _, e := range events {
+			if e == event {
+				subscribers = append(subscribers, w.UserID)
+				break
+			}
+		}
+	}
+	return subscribers, nil
+}
+
+// ✅ Watch an item
+func WatchItem(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var item models.ProjectItem
+	if err := config.DB.First(&item, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Item not found")
+	}
+	if !canAccessBoard(currentUserID, item.BoardID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	return saveWatch(c, currentUserID, &item.ID, nil)
+}
+
+// ✅ Watch every item on a board
+func WatchBoard(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+	if !canAccessBoard(currentUserID, board.ID) {
+		return apperr.Forbidden("You do not have access to this board")
+	}
+
+	return saveWatch(c, currentUserID, nil, &board.ID)
+}
+
+// saveWatch creates or updates the caller's watch from the request body.
+func saveWatch(c *fiber.Ctx, userID uint, itemID, boardID *uint) error {
+	var body watchRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return apperr.BadRequest("Cannot parse JSON")
+		}
+	}
+	events, err := parseWatchEvents(body.Events)
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if itemID != nil {
+		query = query.Where("item_id = ?", *itemID)
+	} else {
+		query = query.Where("board_id = ?", *boardID)
+	}
+
+	var watch models.Watch
+	if err := query.First(&watch).Error; err != nil {
+		watch = models.Watch{UserID: userID, ItemID: itemID, BoardID: boardID, Reason: models.WatchManual}
+	}
+	watch.Events = events
+	if err := config.DB.Save(&watch).Error; err != nil {
+		return apperr.Internal("Could not save watch", err)
+	}
+
+	return c.JSON(watch)
+}
+
+// ✅ Stop watching an item
+func UnwatchItem(c *fiber.Ctx) error {
+	return deleteWatch(c, "item_id")
+}
+
+// ✅ Stop watching a board
+func UnwatchBoard(c *fiber.Ctx) error {
+	return deleteWatch(c, "board_id")
+}
+
+func deleteWatch(c *fiber.Ctx, column string) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	result := config.DB.Where("user_id = ? AND "+column+" = ?", currentUserID, c.Params("id")).Delete(&models.Watch{})
+	if result.Error != nil {
+		return apperr.Internal("Could not remove watch", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperr.NotFound("You are not watching this")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Get the caller's watches and default event choices
+func GetSubscriptions(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var watches []models.Watch
+	if err := config.DB.Where("user_id = ?", currentUserID).Order("created_at DESC").Find(&watches).Error; err != nil {
+		return apperr.Internal("Could not fetch subscriptions", err)
+	}
+
+	defaults := models.SubscriptionDefaults{UserID: currentUserID, Events: models.AllWatchEvents}
+	config.DB.First(&defaults, currentUserID)
+
+	return c.JSON(fiber.Map{
+		"defaults": defaults.Events,
+		"watches":  watches,
+	})
+}
+
+// ✅ Set the events the caller hears about on watches without their own choice
+func UpdateSubscriptionDefaults(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body watchRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	events, err := parseWatchEvents(body.Events)
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	defaults := models.SubscriptionDefaults{UserID: currentUserID, Events: events}
+	if err := config.DB.Save(&defaults).Error; err != nil {
+		return apperr.Internal("Could not save subscription defaults", err)
+	}
+
+	return c.JSON(defaults)
+}
+
+// ✅ Set the events one watch hears about; an empty list falls back to the defaults
+func UpdateWatchEvents(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var watch models.Watch
+	if err := config.DB.Where("user_id = ?", currentUserID).First(&watch, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Subscription not found")
+	}
+
+	var body watchRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+	events, err := parseWatchEvents(body.Events)
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	watch.Events = events
+	if err := config.DB.Save(&watch).Error; err != nil {
+		return apperr.Internal("Could not save subscription", err)
+	}
+
+	return c.JSON(watch)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestSubscribersFor(t *testing.T) {
+	db, fake := openFakeDB(t)
+	saved := config.DB
+	config.DB = db
+	defer func() { config.DB = saved }()
+
+	fake.answer([]string{"id", "board_id"}, []driver.Value{int64(10), int64(3)})
+	fake.answer([]string{"id", "user_id"}, []driver.Value{int64(3), int64(1)})
+	fake.answer([]string{"id", "user_id", "item_id", "board_id", "events"},
+		[]driver.Value{int64(1), int64(5), int64(10), nil, `["comment"]`},
+		[]driver.Value{int64(2), int64(5), nil, int64(3), nil},
+		[]driver.Value{int64(3), int64(1), nil, int64(3), nil},
+		[]driver.Value{int64(4), int64(6), nil, int64(3), nil})
+	fake.answer([]string{"user_id", "events"}, []driver.Value{int64(6), `["due_date"]`})
+
+	got, err := subscribersFor(10, models.EventStatusChange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5's item watch only wants comments, 6's defaults only due dates
+	if !reflect.DeepEqual(got, []uint{1}) {
+		t.Errorf("subscribers = %v, want [1]", got)
+	}
+	sent := fake.sent()
+	if len(sent) < 3 || !strings.Contains(sent[2], "(user_id = 1 OR user_id IN (SELECT `user_id` FROM `board_members` WHERE board_id = 3))") {
+		t.Errorf("watch lookup = %q, want watchers without access to board 3 left out", sent)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errWIPLimitReached = errors.New("WIP limit reached for this status")
+
+// wipCheck is the result of checking a move into a status against its WIP limit.
+type wipCheck struct {
+	Status   string           `json:"status"`
+	Limit    int              `json:"limit"`
+	Load     int64            `json:"load"` // items in the status before the move
+	Policy   models.WIPPolicy `json:"policy"`
+	Exceeded bool             `json:"-"`
+}
+
+func (w *wipCheck) message() string {
+	return fmt.Sprintf("WIP limit for %q is %d and it already holds %d items", w.Status, w.Limit, w.Load)
+}
+
+// checkWIPLimit reports whether moving incoming more items into status on the
+// board goes over that status' WIP limit. It returns nil when the status has
+// no limit. The status row is locked, so run it in the transaction that moves
+// the items, before anything else: two moves into the same status then wait
+// for each other instead of both taking the last free slot.
+func checkWIPLimit(db *gorm.DB, boardID uint, status models.ItemStatus, incoming int64) (*wipCheck, error) {
+	var s models.BoardStatus
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("board_id = ? AND status_key = ?", boardID, string(status)).
+		First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.WIPLimit == nil {
+		return nil, nil
+	}
+
+	var load int64
+	if err := db.Model(&models.ProjectItem{}).Where("board_id = ? AND status = ?", boardID, string(status)).Count(&load).Error; err != nil {
+		return nil, err
+	}
+
+	policy := s.WIPPolicy
+	if policy == "" {
+		policy = models.WIPSoft
+	}
+	return &wipCheck{
+		Status:   s.Key,
+		Limit:    *s.WIPLimit,
+		Load:     load,
+		Policy:   policy,
+		Exceeded: load+incoming > int64(*s.WIPLimit),
+	}, nil
+}
+
+// evaluateWIP decides what happens to a move into status. A soft limit that is
+// exceeded comes back as a check to warn about. A hard limit returns
+// errWIPLimitReached, unless a board admin gives a reason: then override is
+// true and the caller must record it with recordWIPOverride.
+func evaluateWIP(db *gorm.DB, boardID uint, status models.ItemStatus, userID uint, reason string) (check *wipCheck, override bool, err error) {
+	return evaluateWIPFor(db, boardID, status, 1, userID, reason)
+}
+
+// evaluateWIPFor is evaluateWIP for incoming items moving into status at once.
+func evaluateWIPFor(db *gorm.DB, boardID uint, status models.ItemStatus, incoming int64, userID uint, reason string) (check *wipCheck, override bool, err error) {
+	check, err = checkWIPLimit(db, boardID, status, incoming)
+	if err != nil || check == nil || !check.Exceeded {
+		return nil, false, err
+	}
+	if check.Policy == models.WIPSoft {
+		return check, false, nil
+	}
+	if reason != "" && canAdminBoard(userID, boardID) {
+		return check, true, nil
+	}
+	return check, false, errWIPLimitReached
+}
+
+// wipError turns an error from evaluateWIP into the response: a 409 with the
+// check for a hard limit, a 500 for anything else.
+func wipError(check *wipCheck, err error, failed string) error {
+	if errors.Is(err, errWIPLimitReached) {
+		return apperr.Conflict(check.message()).WithCode(codeWIPLimitReached).With("wip", check)
+	}
+	return apperr.Internal(failed, err)
+}
+
+// attachStatusLoads fills in how many items sit in each status.
+func attachStatusLoads(db *gorm.DB, boardID uint, statuses []models.BoardStatus) error {
+	var rows []struct {
+		Status    string
+		ItemCount int64
+	}
+	err := db.Model(&models.ProjectItem{}).
+		Select("status, COUNT(*) AS item_count").
+		Where("board_id = ?", boardID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	loads := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		loads[row.Status] = row.ItemCount
+	}
+	for i := range statuses {
+		load := loads[statuses[i].Key]
+		statuses[i].Load = &load
+	}
+	return nil
+}
+
+// recordWIPOverride writes the audit entry for an admin exceeding a hard limit.
+func recordWIPOverride(tx *gorm.DB, check *wipCheck, boardID, itemID, userID uint, reason string) error {
+	return tx.Create(&models.WIPOverride{
+		BoardID: boardID,
+		ItemID:  itemID,
+		UserID:  userID,
+		Status:  check.Status,
+		Limit:   check.Limit,
+		Load:    check.Load,
+		Reason:  reason,
+	}).Error
+}
+
+// ✅ List WIP limit overrides on a board (board admins only)
+func GetBoardWIPOverrides(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	// Newest first; ids grow with created_at
+	page, err := parsePageRequest(c, listOrder{Desc: true}, "wip_overrides.id")
+	if err != nil {
+		return apperr.Invalid(err.Error())
+	}
+
+	var overrides []models.WIPOverride
+	if err := config.DB.Where("board_id = ?", board.ID).Scopes(page.Scope).Find(&overrides).Error; err != nil {
+		return apperr.Internal("Could not fetch WIP overrides", err)
+	}
+	overrides, hasMore := trimPage(overrides, page.Limit)
+
+	var lastID uint
+	if len(overrides) > 0 {
+		lastID = overrides[len(overrides)-1].ID
+	}
+	return c.JSON(page.result(overrides, hasMore, nil, lastID))
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestCheckWIPLimit(t *testing.T) {
+	db, fake := openFakeDB(t)
+	status := []string{"id", "board_id", "status_key", "wip_limit", "wip_policy"}
+
+	fake.answer(status, []driver.Value{int64(5), int64(2), "doing", int64(3), string(models.WIPHard)})
+	fake.answer([]string{"count"}, []driver.Value{int64(2)})
+	check, err := checkWIPLimit(db, 2, "doing", 2)
+	if err != nil || check == nil || !check.Exceeded || check.Load != 2 || check.Policy != models.WIPHard {
+		t.Errorf("check = %+v, %v; want 2 + 2 over a hard limit of 3", check, err)
+	}
+	// Two moves into the same status must not both see the last free slot
+	if sent := fake.sent(); len(sent) == 0 || !strings.HasSuffix(sent[0], "FOR UPDATE") {
+		t.Errorf("status lookup = %q, want it to lock the row", sent)
+	}
+
+	fake.answer(status, []driver.Value{int64(5), int64(2), "todo", nil, ""})
+	if check, err := checkWIPLimit(db, 2, "todo", 1); check != nil || err != nil {
+		t.Errorf("no limit: check = %+v, %v", check, err)
+	}
+
+	fake.answer(status) // no such status
+	if check, err := checkWIPLimit(db, 2, "gone", 1); check != nil || err != nil {
+		t.Errorf("unknown status: check = %+v, %v", check, err)
+	}
+
+	dbErr := errors.New("Error 1205 (HY000): Lock wait timeout exceeded")
+	fake.fail(dbErr)
+	if _, err := checkWIPLimit(db, 2, "doing", 1); !errors.Is(err, dbErr) {
+		t.Errorf("a failed lookup: err = %v, want it passed on", err)
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// completeStatusSQL matches project_items rows whose status is in the complete
+// category of their board's workflow.
+var completeStatusSQL = "EXISTS (SELECT 1 FROM board_statuses AS bs WHERE bs.board_id = project_items.board_id " +
+	"AND bs.status_key = project_items.status AND bs.category = '" + string(models.CategoryComplete) + "' AND bs.deleted_at IS NULL)"
+
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+var (
+	errUnknownStatus        = errors.New("Status is not part of this board's workflow")
+	errTransitionNotAllowed = errors.New("This board's workflow does not allow that status change")
+)
+
+type workflowRequest struct {
+	Statuses []struct {
+		Key       string `json:"key" validate:"required"`
+		Name      string `json:"name" validate:"required,max=50"`
+		Category  string `json:"category" validate:"required,oneof=not_started active complete"`
+		WIPLimit  *int   `json:"wip_limit" validate:"omitempty,min=1"`
+		WIPPolicy string `json:"wip_policy" validate:"omitempty,oneof=soft hard"`
+	} `json:"statuses" validate:"required,min=1"`
+	Transitions []struct {
+		From string `json:"from" validate:"required"`
+		To   string `json:"to" validate:"required"`
+	} `json:"transitions"` // empty allows every move
+	Remap map[string]string `json:"remap"` // removed status -> replacement, for statuses still in use
+}
+
+// createDefaultWorkflow gives a board the todo / in_progress / done workflow.
+func createDefaultWorkflow(tx *gorm.DB, boardID uint) error {
+	statuses := make([]models.BoardStatus, len(models.DefaultWorkflow))
+	for i, status := range models.DefaultWorkflow {
+		status.BoardID = boardID
+		statuses[i] = status
+	}
+	return tx.Create(&statuses).Error
+}
+
+// MigrateDefaultWorkflows gives every board without a workflow the default one.
+func MigrateDefaultWorkflows() error {
+	var boardIDs []uint
+	err := config.DB.Model(&models.Board{}).
+		Where("NOT EXISTS (SELECT 1 FROM board_statuses WHERE board_statuses.board_id = boards.id)").
+		Pluck("id", &boardIDs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range boardIDs {
+		if err := createDefaultWorkflow(config.DB, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadWorkflow returns a board's statuses in order and its allowed transitions.
+func loadWorkflow(db *gorm.DB, boardID uint) ([]models.BoardStatus, []models.StatusTransition, error) {
+	var statuses []models.BoardStatus
+	if err := db.Where("board_id = ?", boardID).Order("position, id").Find(&statuses).Error; err != nil {
+		return nil, nil, err
+	}
+	var transitions []models.StatusTransition
+	if err := db.Where("board_id = ?", boardID).Find(&transitions).Error; err != nil {
+		return nil, nil, err
+	}
+	return statuses, transitions, nil
+}
+
+func findStatus(statuses []models.BoardStatus, key string) *models.BoardStatus {
+	for i := range statuses {
+		if statuses[i].Key == key {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// initialStatus is the status new items on the board start in: the first
+// not-started status, or the first status if the board has none of those.
+func initialStatus(db *gorm.DB, boardID uint) (models.ItemStatus, error) {
+	statuses, _, err := loadWorkflow(db, boardID)
+	if err != nil {
+		return "", err
+	}
+	if len(statuses) == 0 {
+		return models.StatusTodo, nil
+	}
+	for _, status := range statuses {
+		if status.Category == models.CategoryNotStarted {
+			return models.ItemStatus(status.Key), nil
+		}
+	}
+	return models.ItemStatus(statuses[0].Key), nil
+}
+
+// isCompleteStatus reports whether the status is in the complete category on the board.
+func isCompleteStatus(db *gorm.DB, boardID uint, status models.ItemStatus) (bool, error) {
+	var count int64
+	err := db.Model(&models.BoardStatus{}).
+		Where("board_id = ? AND status_key = ? AND category = ?", boardID, string(status), models.CategoryComplete).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// checkStatusChange validates a status against the board's workflow and, for
+// existing items, that moving from the current status is allowed.
+func checkStatusChange(db *gorm.DB, boardID uint, from, to models.ItemStatus) error {
+	statuses, transitions, err := loadWorkflow(db, boardID)
+	if err != nil {
+		return err
+	}
+	if findStatus(statuses, string(to)) == nil {
+		return errUnknownStatus
+	}
+	if from == "" || from == to || len(transitions) == 0 {
+		return nil
+	}
+	for _, t := range transitions {
+		if t.From == string(from) && t.To == string(to) {
+			return nil
+		}
+	}
+	return errTransitionNotAllowed
+}
+
+// statusChangeError is the response for a checkStatusChange failure. failed
+// describes the request for errors that aren't the client's.
+func statusChangeError(err error, failed string) error {
+	switch {
+	case errors.Is(err, errTransitionNotAllowed):
+		return apperr.Conflict(err.Error()).WithCode(codeTransitionNotAllowed)
+	case errors.Is(err, errUnknownStatus):
+		return apperr.Invalid(err.Error(), apperr.Field("status", err.Error()))
+	}
+	return apperr.Internal(failed, err)
+}
+
+// ✅ Get a board's workflow
+func GetBoardWorkflow(c *fiber.Ctx) error {
+	var board models.Board
+	if err := config.DB.First(&board, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Board not found")
+	}
+
+	statuses, transitions, err := loadWorkflow(config.DB, board.ID)
+	if err == nil {
+		err = attachStatusLoads(config.DB, board.ID, statuses)
+	}
+	if err != nil {
+		return apperr.Internal("Could not fetch workflow", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"statuses":    statuses,
+		"transitions": transitions,
+	})
+}
+
+// remapRemovedStatuses moves the items in statuses the new workflow drops to
+// their replacement in remap. Items in the trash count too, or restoring them
+// would bring back a status the board no longer has. The board's status rows
+// are locked first, so no item can move into a removed status meanwhile.
+func remapRemovedStatuses(tx *gorm.DB, boardID uint, statuses []models.BoardStatus, remap map[string]string) error {
+	var current []models.BoardStatus
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("board_id = ?", boardID).Find(&current).Error; err != nil {
+		return err
+	}
+	for _, s := range current {
+		if findStatus(statuses, s.Key) != nil {
+			continue
+		}
+		var inUse int64
+		err := tx.Unscoped().Model(&models.ProjectItem{}).Where("board_id = ? AND status = ?", boardID, s.Key).Count(&inUse).Error
+		if err != nil {
+			return err
+		}
+		if inUse == 0 {
+			continue
+		}
+		target, ok := remap[s.Key]
+		if !ok {
+			return apperr.Conflict(fmt.Sprintf("Status %q is still used by %d items, add it to remap", s.Key, inUse)).
+				WithCode(codeStatusInUse).With("status", s.Key).With("items", inUse)
+		}
+		if findStatus(statuses, target) == nil {
+			return apperr.Invalid(fmt.Sprintf("remap target %q is not in the new workflow", target))
+		}
+		err = tx.Unscoped().Model(&models.ProjectItem{}).Where("board_id = ? AND status = ?", boardID, s.Key).Update("status", target).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ✅ Replace a board's workflow (board admins only)
+func UpdateBoardWorkflow(c *fiber.Ctx) error {
+	board, err := loadAdminBoard(c)
+	if board == nil {
+		return err
+	}
+
+	var body workflowRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Cannot parse JSON")
+	}
+
+	statuses := make([]models.BoardStatus, 0, len(body.Statuses))
+	categories := map[models.StatusCategory]bool{}
+	for i, s := range body.Statuses {
+		category := models.StatusCategory(s.Category)
+		switch category {
+		case models.CategoryNotStarted, models.CategoryActive, models.CategoryComplete:
+		default:
+			return apperr.Invalid("category must be not_started, active or complete")
+		}
+		if !statusKeyPattern.MatchString(s.Key) || s.Name == "" {
+			return apperr.Invalid("Each status needs a name and a key of lowercase letters, digits or underscores")
+		}
+		if findStatus(statuses, s.Key) != nil {
+			return apperr.Invalid(fmt.Sprintf("Duplicate status key %q", s.Key))
+		}
+		policy := models.WIPPolicy(s.WIPPolicy)
+		if policy == "" {
+			policy = models.WIPSoft
+		}
+		if (policy != models.WIPSoft && policy != models.WIPHard) || (s.WIPLimit != nil && *s.WIPLimit < 1) {
+			return apperr.Invalid("wip_limit must be at least 1 and wip_policy soft or hard")
+		}
+		categories[category] = true
+		statuses = append(statuses, models.BoardStatus{
+			BoardID:   board.ID,
+			Key:       s.Key,
+			Name:      s.Name,
+			Category:  category,
+			Position:  i,
+			WIPLimit:  s.WIPLimit,
+			WIPPolicy: policy,
+		})
+	}
+	if !categories[models.CategoryNotStarted] || !categories[models.CategoryComplete] {
+		return apperr.Invalid("A workflow needs at least one not_started and one complete status")
+	}
+
+	transitions := make([]models.StatusTransition, 0, len(body.Transitions))
+	for _, t := range body.Transitions {
+		if findStatus(statuses, t.From) == nil || findStatus(statuses, t.To) == nil || t.From == t.To {
+			return apperr.Invalid(fmt.Sprintf("Invalid transition %q -> %q", t.From, t.To))
+		}
+		transitions = append(transitions, models.StatusTransition{BoardID: board.ID, From: t.From, To: t.To})
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := remapRemovedStatuses(tx, board.ID, statuses, body.Remap); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("board_id = ?", board.ID).Delete(&models.BoardStatus{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("board_id = ?", board.ID).Delete(&models.StatusTransition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&statuses).Error; err != nil {
+			return err
+		}
+		if len(transitions) > 0 {
+			return tx.Create(&transitions).Error
+		}
+		return nil
+	})
+	var invalid *apperr.Error
+	if errors.As(err, &invalid) {
+		return invalid
+	}
+	if err != nil {
+		return apperr.Internal("Could not update workflow", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"statuses":    statuses,
+		"transitions": transitions,
+	})
+}
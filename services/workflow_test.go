@@ -0,0 +1,51 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/models"
+)
+
+func TestRemapRemovedStatuses(t *testing.T) {
+	db, fake := openFakeDB(t)
+	current := func() {
+		fake.answer([]string{"id", "board_id", "status_key"},
+			[]driver.Value{int64(1), int64(3), "todo"},
+			[]driver.Value{int64(2), int64(3), "review"})
+	}
+	next := []models.BoardStatus{{Key: "todo"}, {Key: "done"}}
+
+	// Trashed items in a removed status still need a new one
+	current()
+	fake.answer([]string{"count"}, []driver.Value{int64(2)})
+	err := remapRemovedStatuses(db, 3, next, nil)
+	var conflict *apperr.Error
+	if !errors.As(err, &conflict) || conflict.Code != codeStatusInUse {
+		t.Fatalf("err = %v, want the status in use", err)
+	}
+	sent := fake.sent()
+	if !strings.HasSuffix(sent[0], "FOR UPDATE") || strings.Contains(sent[1], "deleted_at") {
+		t.Errorf("sent %q, want the statuses locked and trashed items counted", sent)
+	}
+
+	current()
+	fake.answer([]string{"count"}, []driver.Value{int64(2)})
+	if err := remapRemovedStatuses(db, 3, next, map[string]string{"review": "done"}); err != nil {
+		t.Fatal(err)
+	}
+	sent = fake.sent()
+	if len(sent) != 3 || !strings.Contains(sent[2], "SET `status`=\"done\"") || strings.Contains(sent[2], "deleted_at` IS NULL") {
+		t.Errorf("sent %q, want every item in review moved to done", sent)
+	}
+
+	dbErr := errors.New("Error 1205 (HY000): Lock wait timeout exceeded")
+	current()
+	fake.fail(dbErr)
+	if err := remapRemovedStatuses(db, 3, next, nil); !errors.Is(err, dbErr) {
+		t.Errorf("a failed count: err = %v, want it passed on", err)
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/clem-kay/mini-trello/apperr"
+	"github.com/clem-kay/mini-trello/config"
+	"github.com/clem-kay/mini-trello/models"
+	"github.com/clem-kay/mini-trello/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errTimerRunning = errors.New("You already have a running timer, stop it first")
+
+type timerRequest struct {
+	ItemID uint   `json:"item_id" validate:"required"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+type worklogRequest struct {
+	ItemID    uint   `json:"item_id" validate:"required"`
+	Duration  string `json:"duration" validate:"required"` // Go duration, e.g. "1h30m"
+	StartedAt string `json:"started_at"`                   // RFC3339, defaults to now minus duration
+	Note      string `json:"note" validate:"max=255"`
+}
+
+// attachTimeSpent fills in the logged time of every item.
+func attachTimeSpent(items []models.ProjectItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+
+	var rows []struct {
+		ItemID uint
+		Total  int64
+	}
+	err := config.DB.Model(&models.Worklog{}).
+		Select("item_id, SUM(duration_seconds) AS total").
+		Where("item_id IN ? AND ended_at IS NOT NULL", ids).
+		Group("item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	totals := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		totals[row.ItemID] = row.Total
+	}
+	for i := range items {
+		items[i].TimeSpent = totals[items[i].ID]
+	}
+	return nil
+}
+
+// checkWorklogNote keeps a worklog note within its column.
+func checkWorklogNote(note string) error {
+	if utf8.RuneCountInString(note) > 255 {
+		return apperr.Invalid("note must be at most 255 characters long", apperr.Field("note", "must be at most 255 characters long"))
+	}
+	return nil
+}
+
+// ✅ Start a timer on an item (one running timer per user)
+func StartTimer(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body timerRequest
+	if err := c.BodyParser(&body); err != nil || body.ItemID == 0 {
+		return apperr.Invalid("item_id is required", apperr.Field("item_id", "is required"))
+	}
+	if err := checkWorklogNote(body.Note); err != nil {
+		return err
+	}
+
+	item, err := loadAccessibleItem(currentUserID, body.ItemID)
+	if item == nil {
+		return err
+	}
+
+	worklog := models.Worklog{
+		ItemID:    item.ID,
+		UserID:    currentUserID,
+		StartedAt: time.Now(),
+		Note:      body.Note,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the user row so two concurrent starts cannot both succeed.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.User{}, currentUserID).Error; err != nil {
+			return err
+		}
+		var running int64
+		if err := tx.Model(&models.Worklog{}).Where("user_id = ? AND ended_at IS NULL", currentUserID).Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return errTimerRunning
+		}
+		return tx.Create(&worklog).Error
+	})
+	if errors.Is(err, errTimerRunning) {
+		return apperr.Conflict(err.Error())
+	}
+	if err != nil {
+		return apperr.Internal("Could not start timer", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(worklog)
+}
+
+// ✅ Stop the caller's running timer
+func StopTimer(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var worklog models.Worklog
+	if err := config.DB.Where("user_id = ? AND ended_at IS NULL", currentUserID).First(&worklog).Error; err != nil {
+		return apperr.NotFound("No running timer")
+	}
+
+	now := time.Now()
+	worklog.EndedAt = &now
+	worklog.DurationSeconds = int64(now.Sub(worklog.StartedAt).Seconds())
+	if err := config.DB.Save(&worklog).Error; err != nil {
+		return apperr.Internal("Could not stop timer", err)
+	}
+
+	return c.JSON(worklog)
+}
+
+// ✅ Get the caller's running timer
+func GetRunningTimer(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var worklog models.Worklog
+	if err := config.DB.Where("user_id = ? AND ended_at IS NULL", currentUserID).First(&worklog).Error; err != nil {
+		return apperr.NotFound("No running timer")
+	}
+
+	return c.JSON(fiber.Map{
+		"worklog":         worklog,
+		"elapsed_seconds": int64(time.Since(worklog.StartedAt).Seconds()),
+	})
+}
+
+// ✅ Log time by hand
+func CreateWorklog(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var body worklogRequest
+	if err := c.BodyParser(&body); err != nil {
+		return apperr.BadRequest("Invalid request body")
+	}
+	if err := checkWorklogNote(body.Note); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(body.Duration)
+	if err != nil || duration <= 0 {
+		return apperr.Invalid("Invalid duration, use a positive value like 45m or 1h30m", apperr.Field("duration", "must be a positive duration like 45m or 1h30m"))
+	}
+
+	startedAt := time.Now().Add(-duration)
+	if body.StartedAt != "" {
+		startedAt, err = time.Parse(time.RFC3339, body.StartedAt)
+		if err != nil {
+			return apperr.Invalid("Invalid started_at format, must be RFC3339 (e.g. 2025-09-20T15:04:05Z)", apperr.Field("started_at", "must be RFC3339"))
+		}
+	}
+	endedAt := startedAt.Add(duration)
+
+	item, err := loadAccessibleItem(currentUserID, body.ItemID)
+	if item == nil {
+		return err
+	}
+
+	worklog := models.Worklog{
+		ItemID:          item.ID,
+		UserID:          currentUserID,
+		StartedAt:       startedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: int64(duration.Seconds()),
+		Note:            body.Note,
+	}
+	if err := config.DB.Create(&worklog).Error; err != nil {
+		return apperr.Internal("Could not create worklog", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(worklog)
+}
+
+// ✅ Delete one of the caller's worklogs
+func DeleteWorklog(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	var worklog models.Worklog
+	if err := config.DB.First(&worklog, c.Params("id")).Error; err != nil {
+		return apperr.NotFound("Worklog not found")
+	}
+	if worklog.UserID != currentUserID {
+		return apperr.Forbidden("You do not own this worklog")
+	}
+
+	if err := config.DB.Delete(&worklog).Error; err != nil {
+		return apperr.Internal("Could not delete worklog", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ✅ Get the worklogs of an item with their total
+func GetItemWorklogs(c *fiber.Ctx) error {
+	item, err := loadItemParam(c)
+	if item == nil {
+		return err
+	}
+
+	var worklogs []models.Worklog
+	if err := config.DB.Where("item_id = ?", item.ID).Order("started_at").Find(&worklogs).Error; err != nil {
+		return apperr.Internal("Could not fetch worklogs", err)
+	}
+
+	var total int64
+	for _, w := range worklogs {
+		total += w.DurationSeconds
+	}
+
+	return c.JSON(fiber.Map{
+		"worklogs":      worklogs,
+		"total_seconds": total,
+	})
+}
+
+type worklogReportRow struct {
+	UserID  uint   `json:"user_id"`
+	BoardID uint   `json:"board_id"`
+	Day     string `json:"date"`
+	Seconds int64  `json:"seconds"`
+}
+
+// ✅ Time report by user, board and day
+// Query: user_id, board_id, from, to (YYYY-MM-DD, to is inclusive), format=csv
+func GetWorklogReport(c *fiber.Ctx) error {
+	currentUserID := utils.GetIDFromContext(c)
+	if currentUserID == 0 {
+		return apperr.Unauthorized("Unauthorized, user needs to be logged in")
+	}
+
+	boardIDs, err := accessibleBoardIDs(currentUserID)
+	if err != nil {
+		return apperr.Internal("Could not build report", err)
+	}
+
+	query := config.DB.Model(&models.Worklog{}).
+		Select("worklogs.user_id, project_items.board_id, DATE(worklogs.started_at) AS day, SUM(worklogs.duration_seconds) AS seconds").
+		Joins("JOIN project_items ON project_items.id = worklogs.item_id AND project_items.deleted_at IS NULL").
+		Where("worklogs.ended_at IS NOT NULL AND project_items.board_id IN ?", boardIDs)
+
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return apperr.Invalid("Invalid user_id", apperr.Field("user_id", "must be a user ID"))
+		}
+		query = query.Where("worklogs.user_id = ?", uint(id))
+	}
+	if v := c.Query("board_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return apperr.Invalid("Invalid board_id", apperr.Field("board_id", "must be a board ID"))
+		}
+		query = query.Where("project_items.board_id = ?", uint(id))
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return apperr.Invalid("Invalid from date, use YYYY-MM-DD", apperr.Field("from", "must be YYYY-MM-DD"))
+		}
+		query = query.Where("worklogs.started_at >= ?", from)
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return apperr.Invalid("Invalid to date, use YYYY-MM-DD", apperr.Field("to", "must be YYYY-MM-DD"))
+		}
+		query = query.Where("worklogs.started_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var rows []worklogReportRow
+	if err := query.Group("worklogs.user_id, project_items.board_id, day").Order("day, worklogs.user_id").Scan(&rows).Error; err != nil {
+		return apperr.Internal("Could not build report", err)
+	}
+
+	var total int64
+	for i := range rows {
+		// Drivers return DATE() either as "2006-01-02" or as a full timestamp
+		if len(rows[i].Day) > 10 {
+			rows[i].Day = rows[i].Day[:10]
+		}
+		total += rows[i].Seconds
+	}
+
+	if c.Query("format") == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="worklog-report.csv"`)
+
+		w := csv.NewWriter(c)
+		w.Write([]string{"date", "user_id", "board_id", "hours"})
+		for _, row := range rows {
+			w.Write([]string{
+				row.Day,
+				strconv.FormatUint(uint64(row.UserID), 10),
+				strconv.FormatUint(uint64(row.BoardID), 10),
+				strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	return c.JSON(fiber.Map{
+		"rows":          rows,
+		"total_seconds": total,
+	})
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckWorklogNote(t *testing.T) {
+	if err := checkWorklogNote(strings.Repeat("ñ", 255)); err != nil {
+		t.Errorf("a 255 character note: %v", err)
+	}
+	if err := checkWorklogNote(strings.Repeat("n", 256)); err == nil {
+		t.Error("a 256 character note was accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/clem-kay/mini-trello/apperr"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -75,15 +76,12 @@ func GetUserIDFromToken(tokenString string) (uint, error) {
 	return uint(userIDFloat), nil
 }
 
-func GetIDParam(c *fiber.Ctx) string {
+func GetIDParam(c *fiber.Ctx) (string, error) {
 	id := c.Params("id")
 	if id == "" {
-		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "ID is required",
-		})
-		return ""
+		return "", apperr.Invalid("ID is required", apperr.Field("id", "is required"))
 	}
-	return id
+	return id, nil
 }
 
 func GetIDFromContext(c *fiber.Ctx) uint {
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RRule is the subset of an iCalendar (RFC 5545) recurrence rule we support:
+// FREQ, INTERVAL, COUNT, UNTIL, BYDAY (weekly) and BYMONTHDAY (monthly).
+type RRule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	UntilLocal bool // UNTIL had no Z: a wall-clock time in the series' time zone
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// maxRRuleIterations stops runaway rules (e.g. BYMONTHDAY=31 with FREQ=MONTHLY;INTERVAL=2).
+const maxRRuleIterations = 10000
+
+func ParseRRule(rule string) (*RRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	r := &RRule{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch v := strings.ToUpper(value); v {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.Freq = v
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, local, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			r.Until = &until
+			r.UntilLocal = local
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", code)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY value %q", v)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("rrule needs a FREQ")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("rrule cannot have both COUNT and UNTIL")
+	}
+	if len(r.ByDay) > 0 && r.Freq != "WEEKLY" {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != "MONTHLY" {
+		return nil, errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+// parseRRuleTime reads an UNTIL value. Only a value ending in Z is UTC; the
+// others are local to the series (the second result), and a date alone
+// covers that whole day.
+func parseRRuleTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), true, nil
+	}
+	return time.Time{}, false, errors.New("bad time")
+}
+
+// Next returns the first occurrence strictly after `after`, counting from dtstart.
+// Occurrences keep dtstart's wall-clock time in dtstart's location, so a 09:00
+// Europe/Berlin rule stays at 09:00 across daylight saving changes.
+// dtstart is always the first occurrence, as RFC 5545 requires, even when it
+// doesn't match the rule. The second result is false once the rule is exhausted.
+func (r *RRule) Next(dtstart, after time.Time) (time.Time, bool) {
+	until := r.untilIn(dtstart.Location())
+	if dtstart.After(after) {
+		return dtstart, until == nil || !dtstart.After(*until)
+	}
+
+	emitted := 1 // dtstart
+	for period := 0; period < maxRRuleIterations; period++ {
+		for _, candidate := range r.periodCandidates(dtstart, period) {
+			if !candidate.After(dtstart) {
+				continue
+			}
+			emitted++
+			if r.Count > 0 && emitted > r.Count {
+				return time.Time{}, false
+			}
+			if until != nil && candidate.After(*until) {
+				return time.Time{}, false
+			}
+			if candidate.After(after) {
+				return candidate, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// untilIn resolves UNTIL for a series in loc.
+func (r *RRule) untilIn(loc *time.Location) *time.Time {
+	if r.Until == nil || !r.UntilLocal {
+		return r.Until
+	}
+	u := r.Until
+	local := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+	return &local
+}
+
+// periodCandidates returns the sorted occurrences inside the n-th period of the rule.
+func (r *RRule) periodCandidates(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+
+	switch r.Freq {
+	case "DAILY":
+		return []time.Time{time.Date(y, m, d+step, hh, mm, ss, 0, loc)}
+
+	case "WEEKLY":
+		if len(r.ByDay) == 0 {
+			return []time.Time{time.Date(y, m, d+7*step, hh, mm, ss, 0, loc)}
+		}
+		// Weeks start on Monday (RFC 5545 default WKST).
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := d - offset + 7*step
+		out := make([]time.Time, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			out = append(out, time.Date(y, m, monday+(int(day)+6)%7, hh, mm, ss, 0, loc))
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+
+	case "MONTHLY":
+		month := time.Month(int(m) + step)
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{d}
+		}
+		last := time.Date(y, month+1, 0, 0, 0, 0, 0, loc).Day()
+		out := make([]time.Time, 0, len(days))
+		for _, day := range days {
+			if day < 0 {
+				day = last + 1 + day
+			}
+			// Months without that day are skipped, as RFC 5545 requires.
+			if day < 1 || day > last {
+				continue
+			}
+			out = append(out, time.Date(y, month, day, hh, mm, ss, 0, loc))
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+		return out
+
+	case "YEARLY":
+		candidate := time.Date(y+step, m, d, hh, mm, ss, 0, loc)
+		if candidate.Month() != m {
+			return nil // Feb 29 in a non-leap year
+		}
+		return []time.Time{candidate}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the tests need America/New_York wherever they run
+)
+
+// occurrences lists up to limit occurrences of rule from dtstart by walking Next.
+func occurrences(t *testing.T, rule string, dtstart time.Time, limit int) []time.Time {
+	t.Helper()
+	r, err := ParseRRule(rule)
+	if err != nil {
+		t.Fatalf("ParseRRule(%q): %v", rule, err)
+	}
+	var out []time.Time
+	after := dtstart.Add(-time.Second)
+	for len(out) < limit {
+		next, ok := r.Next(dtstart, after)
+		if !ok {
+			break
+		}
+		out = append(out, next)
+		after = next
+	}
+	return out
+}
+
+// The examples from RFC 5545, section 3.8.5.3, all in America/New_York.
+func TestRRuleNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, ny) }
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		count   int         // how many occurrences the rule has; -1 for endless
+		want    []time.Time // the first ones
+		last    time.Time   // the last one, when the rule ends
+	}{
+		{
+			name:    "daily for 10 occurrences",
+			rule:    "FREQ=DAILY;COUNT=10",
+			dtstart: at(1997, 9, 2),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 3), at(1997, 9, 4)},
+			last:    at(1997, 9, 11),
+		},
+		{
+			name:    "daily until December 24, 1997",
+			rule:    "FREQ=DAILY;UNTIL=19971224T000000Z",
+			dtstart: at(1997, 9, 2),
+			count:   113,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 3)},
+			last:    at(1997, 12, 23),
+		},
+		{
+			name:    "every other day, forever",
+			rule:    "FREQ=DAILY;INTERVAL=2",
+			dtstart: at(1997, 9, 2),
+			count:   -1,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 4), at(1997, 9, 6)},
+		},
+		{
+			name:    "every 10 days, 5 occurrences",
+			rule:    "FREQ=DAILY;INTERVAL=10;COUNT=5",
+			dtstart: at(1997, 9, 2),
+			count:   5,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 12), at(1997, 9, 22), at(1997, 10, 2), at(1997, 10, 12)},
+			last:    at(1997, 10, 12),
+		},
+		{
+			name:    "weekly for 10 occurrences, across the DST change",
+			rule:    "FREQ=WEEKLY;COUNT=10",
+			dtstart: at(1997, 9, 2),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 9)},
+			last:    at(1997, 11, 4),
+		},
+		{
+			name:    "weekly on Tuesday and Thursday for 10 occurrences",
+			rule:    "FREQ=WEEKLY;COUNT=10;BYDAY=TU,TH",
+			dtstart: at(1997, 9, 2),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 4), at(1997, 9, 9), at(1997, 9, 11)},
+			last:    at(1997, 10, 2),
+		},
+		{
+			name:    "every other week on Monday, Wednesday and Friday until December 24, 1997",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;BYDAY=MO,WE,FR",
+			dtstart: at(1997, 9, 1),
+			count:   25,
+			want:    []time.Time{at(1997, 9, 1), at(1997, 9, 3), at(1997, 9, 5), at(1997, 9, 15)},
+			last:    at(1997, 12, 22),
+		},
+		{
+			name:    "monthly on the 2nd and 15th for 10 occurrences",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=2,15",
+			dtstart: at(1997, 9, 2),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 15), at(1997, 10, 2), at(1997, 10, 15)},
+			last:    at(1998, 1, 15),
+		},
+		{
+			name:    "monthly on the first and last day for 10 occurrences",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=1,-1",
+			dtstart: at(1997, 9, 30),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 30), at(1997, 10, 1), at(1997, 10, 31), at(1997, 11, 1)},
+			last:    at(1998, 2, 1),
+		},
+		{
+			name:    "monthly on the third-to-last day, forever",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-3",
+			dtstart: at(1997, 9, 28),
+			count:   -1,
+			want:    []time.Time{at(1997, 9, 28), at(1997, 10, 29), at(1997, 11, 28), at(1997, 12, 29), at(1998, 1, 29), at(1998, 2, 26)},
+		},
+		{
+			name:    "every 18 months on the 10th through 15th for 10 occurrences",
+			rule:    "FREQ=MONTHLY;INTERVAL=18;COUNT=10;BYMONTHDAY=10,11,12,13,14,15",
+			dtstart: at(1997, 9, 10),
+			count:   10,
+			want:    []time.Time{at(1997, 9, 10), at(1997, 9, 11), at(1997, 9, 12), at(1997, 9, 13), at(1997, 9, 14), at(1997, 9, 15), at(1999, 3, 10)},
+			last:    at(1999, 3, 13),
+		},
+		{
+			name:    "monthly on the 31st skips the shorter months",
+			rule:    "FREQ=MONTHLY;COUNT=4",
+			dtstart: at(1997, 10, 31),
+			count:   4,
+			want:    []time.Time{at(1997, 10, 31), at(1997, 12, 31), at(1998, 1, 31), at(1998, 3, 31)},
+			last:    at(1998, 3, 31),
+		},
+		{
+			name:    "yearly on February 29 only in leap years",
+			rule:    "FREQ=YEARLY;COUNT=3",
+			dtstart: at(2000, 2, 29),
+			count:   3,
+			want:    []time.Time{at(2000, 2, 29), at(2004, 2, 29), at(2008, 2, 29)},
+			last:    at(2008, 2, 29),
+		},
+		{
+			name:    "DTSTART counts as the first occurrence even off the rule",
+			rule:    "FREQ=WEEKLY;COUNT=3;BYDAY=MO",
+			dtstart: at(1997, 9, 2), // a Tuesday
+			count:   3,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 8), at(1997, 9, 15)},
+			last:    at(1997, 9, 15),
+		},
+		{
+			name:    "floating UNTIL is a New York wall-clock time",
+			rule:    "FREQ=DAILY;UNTIL=19970905T090000",
+			dtstart: at(1997, 9, 2),
+			count:   4,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 3), at(1997, 9, 4), at(1997, 9, 5)},
+			last:    at(1997, 9, 5),
+		},
+		{
+			name:    "date-only UNTIL includes that day",
+			rule:    "FREQ=DAILY;UNTIL=19970905",
+			dtstart: at(1997, 9, 2),
+			count:   4,
+			want:    []time.Time{at(1997, 9, 2), at(1997, 9, 3), at(1997, 9, 4), at(1997, 9, 5)},
+			last:    at(1997, 9, 5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := 200
+			got := occurrences(t, tt.rule, tt.dtstart, limit)
+			if tt.count >= 0 && len(got) != tt.count {
+				t.Errorf("got %d occurrences, want %d", len(got), tt.count)
+			}
+			if tt.count < 0 && len(got) != limit {
+				t.Errorf("got %d occurrences, want the rule to go on", len(got))
+			}
+			for i, want := range tt.want {
+				if i >= len(got) {
+					break
+				}
+				if !got[i].Equal(want) || got[i].Hour() != 9 {
+					t.Errorf("occurrence %d = %v, want %v", i+1, got[i], want)
+				}
+			}
+			if !tt.last.IsZero() && len(got) > 0 && !got[len(got)-1].Equal(tt.last) {
+				t.Errorf("last occurrence = %v, want %v", got[len(got)-1], tt.last)
+			}
+		})
+	}
+}
+
+func TestRRuleNextAfter(t *testing.T) {
+	r, err := ParseRRule("RRULE:FREQ=WEEKLY;BYDAY=MO,TH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtstart := time.Date(2024, 3, 25, 9, 0, 0, 0, berlin) // Monday before the DST change
+
+	tests := []struct {
+		after, want time.Time
+	}{
+		{dtstart, time.Date(2024, 3, 28, 9, 0, 0, 0, berlin)},
+		{time.Date(2024, 3, 28, 9, 0, 0, 0, berlin), time.Date(2024, 4, 1, 9, 0, 0, 0, berlin)},
+		{time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 9, 0, 0, 0, berlin)},
+		{dtstart.Add(-time.Hour), dtstart},
+	}
+	for _, tt := range tests {
+		got, ok := r.Next(dtstart, tt.after)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("Next after %v = %v, %v; want %v", tt.after, got, ok, tt.want)
+		}
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;COUNT=3;UNTIL=19971224T000000Z",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ",
+	} {
+		if _, err := ParseRRule(rule); err == nil {
+			t.Errorf("ParseRRule(%q) = nil error, want one", rule)
+		}
+	}
+}